@@ -0,0 +1,214 @@
+// Package assistant answers the questions of the users about the network
+// independently of the voice platform asking them. Queries and answers are
+// typed, the answers holding both structured data and rendered sentences.
+package assistant
+
+import (
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/gophersch/tlgo"
+	"github.com/yageek/tl-ai/geo"
+	"github.com/yageek/tl-ai/i18n"
+	"github.com/yageek/tl-ai/realtime"
+	"github.com/yageek/tl-ai/search"
+	"github.com/yageek/tl-ai/storage"
+)
+
+const (
+	// stopCandidatesCount is the maximum number of stops proposed to the user
+	stopCandidatesCount = 3
+	// ambiguityMargin is the score difference under which two stops are ambiguous
+	ambiguityMargin = 0.1
+	// nearestStopsCount is the number of stops around the user looked for a line
+	nearestStopsCount = 10
+)
+
+// StopRole tells which stop of a query a name refers to
+type StopRole int
+
+const (
+	OriginStop StopRole = iota
+	DirectionStop
+	DestinationStop
+)
+
+// Failure is an error the user is told about with its utterance
+type Failure struct {
+	Utterance
+	Err error
+}
+
+func (f *Failure) Error() string {
+	if f.Err != nil {
+		return fmt.Sprintf("%s: %v", f.Text, f.Err)
+	}
+	return f.Text
+}
+
+func failure(err error, speech Utterance) *Failure {
+	return &Failure{Utterance: speech, Err: err}
+}
+
+// AmbiguousStopError is returned when several stops match a name. The
+// query can be asked again with the candidate chosen by the user.
+type AmbiguousStopError struct {
+	Role       StopRole
+	Name       string
+	Candidates []string
+}
+
+func (e *AmbiguousStopError) Error() string {
+	return fmt.Sprintf("the stop %s is ambiguous: %v", e.Name, e.Candidates)
+}
+
+// Question asks the user to choose one of the candidates
+func (e *AmbiguousStopError) Question(language i18n.Language) string {
+	p := i18n.NewPrinter(language)
+	return p.Sprintf(i18n.AmbiguousStop, p.List(i18n.Or, e.Candidates))
+}
+
+// Speech returns the sentence telling an error of the assistant to the user
+func Speech(err error, language i18n.Language) Utterance {
+	switch e := err.(type) {
+	case *Failure:
+		return e.Utterance
+	case *AmbiguousStopError:
+		return Plain(e.Question(language))
+	}
+	return say(i18n.NewPrinter(language), i18n.InternalError)
+}
+
+// Assistant answers the queries from the static data of the network and
+// the real time departures.
+type Assistant struct {
+	store *storage.Store
+	graph *search.BFS
+	// router finds the itineraries on the timetable, nil without timetable
+	router *search.Router
+	// provider lists the real time departures
+	provider realtime.DeparturesProvider
+	// lastKnown are told when the provider is too slow without timetable
+	lastKnown *lastKnownDepartures
+	// now is replaceable for the tests
+	now func() time.Time
+}
+
+// New returns an assistant sharing the store and the search graphs, the
+// router being nil when the store holds no timetable
+func New(store *storage.Store, graph *search.BFS, router *search.Router, provider realtime.DeparturesProvider) *Assistant {
+	return &Assistant{store: store, graph: graph, router: router, provider: provider, lastKnown: newLastKnownDepartures(), now: time.Now}
+}
+
+// resolveStop returns the stop matching the spoken name, or an
+// AmbiguousStopError when several stops match it.
+func (a *Assistant) resolveStop(p i18n.Printer, role StopRole, name string) (tlgo.Stop, error) {
+
+	matches, err := a.store.FindStops(name, stopCandidatesCount)
+	if err != nil {
+		log.Printf("The stop %s has not been found in the index\n", name)
+		return tlgo.Stop{}, failure(err, say(p, i18n.StopNotFound, name))
+	}
+
+	if matches[0].Stop.Name == name {
+		return matches[0].Stop, nil
+	}
+
+	candidates := []string{}
+	for _, match := range matches {
+		if match.Score >= matches[0].Score-ambiguityMargin {
+			candidates = append(candidates, match.Stop.Name)
+		}
+	}
+
+	if len(candidates) == 1 {
+		return matches[0].Stop, nil
+	}
+
+	log.Printf("The stop %s is ambiguous: %v\n", name, candidates)
+	return tlgo.Stop{}, &AmbiguousStopError{Role: role, Name: name, Candidates: candidates}
+}
+
+// ChooseStop finds the stop chosen by the user among the candidates
+// either by its position, starting at 1, or by its name.
+func (a *Assistant) ChooseStop(candidates []string, ordinal int, name string) (string, bool) {
+
+	if index := ordinal - 1; index >= 0 && index < len(candidates) {
+		return candidates[index], true
+	}
+
+	if name == "" {
+		return "", false
+	}
+
+	matches, err := a.store.FindStops(name, stopCandidatesCount*3)
+	if err != nil {
+		return "", false
+	}
+
+	for _, match := range matches {
+		for _, candidate := range candidates {
+			if match.Stop.Name == candidate {
+				return candidate, true
+			}
+		}
+	}
+	return "", false
+}
+
+// resolveLine returns the line whose name best matches the spoken one
+func (a *Assistant) resolveLine(p i18n.Printer, name string) (tlgo.Line, error) {
+	matches, err := a.store.FindLines(name, 1)
+	if err != nil {
+		log.Printf("The line %s has not been found in the store: %v\n", name, err)
+		return tlgo.Line{}, failure(err, say(p, i18n.LineNotFound, name))
+	}
+	return matches[0].Line, nil
+}
+
+// originStop resolves the named origin stop or, when no name is provided,
+// the stop closest to the position. When a line is provided, the closest
+// stop served by the line is chosen.
+func (a *Assistant) originStop(p i18n.Printer, name string, position geo.Point, line *tlgo.Line) (tlgo.Stop, error) {
+
+	if name != "" {
+		return a.resolveStop(p, OriginStop, name)
+	}
+
+	if position.IsZero() {
+		log.Printf("Neither the origin nor the device location has been provided\n")
+		return tlgo.Stop{}, failure(nil, say(p, i18n.UnknownPosition))
+	}
+
+	stops, err := a.store.GetNearestStops(position.Lat, position.Lng, nearestStopsCount)
+	if err != nil {
+		log.Printf("No stop found near the user: %v\n", err)
+		return tlgo.Stop{}, failure(err, say(p, i18n.NoStopNearby))
+	}
+
+	if line == nil {
+		log.Printf("Nearest stop is %s at %.0f meters\n", stops[0].Stop.Name, stops[0].Distance)
+		return stops[0].Stop, nil
+	}
+
+	for _, candidate := range stops {
+		if servesStop(*line, candidate.Stop) {
+			log.Printf("Nearest stop of line %s is %s at %.0f meters\n", line.ShortName, candidate.Stop.Name, candidate.Distance)
+			return candidate.Stop, nil
+		}
+	}
+
+	log.Printf("No stop of line %s found near the user\n", line.ShortName)
+	return tlgo.Stop{}, failure(storage.ErrNotFound, say(p, i18n.NoLineStopNearby, line.ShortName))
+}
+
+// servesStop tells if the line stops at the stop
+func servesStop(line tlgo.Line, stop tlgo.Stop) bool {
+	for _, name := range stop.LinesShortName {
+		if name == line.ShortName {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,555 @@
+package assistant
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gophersch/tlgo"
+	"github.com/yageek/tl-ai/geo"
+	"github.com/yageek/tl-ai/i18n"
+)
+
+const (
+	// MaxDeparturesCount is the maximum number of departures told at once
+	MaxDeparturesCount = 5
+	// boardDeparturesCount is the number of departures told by default on a board
+	boardDeparturesCount = 4
+)
+
+// NextDepartureQuery asks for the next departures from a stop. Only the
+// origin, or the position of the user, is required.
+type NextDepartureQuery struct {
+	// Line is the spoken line name, all the lines are considered when empty
+	Line string
+	// Origin is the spoken origin stop, the stop closest to Position is used when empty
+	Origin   string
+	Position geo.Point
+	// Direction is the spoken direction stop, the departures of all the
+	// directions are told when empty
+	Direction string
+	// At is the time after which the departures are looked for, now when zero
+	At time.Time
+	// Offset is the number of departures already told, none when negative
+	Offset int
+	// After is the time of the last departure already told, only the
+	// departures leaving in a later minute are told when it is set
+	After time.Time
+	// Count is the number of departures to tell, a default one when zero
+	// or negative
+	Count int
+	// Language is the language of the answer
+	Language i18n.Language
+}
+
+// DepartureMode tells how the departures of an answer have been selected
+type DepartureMode int
+
+const (
+	// RouteDepartures are the departures of a line in a direction
+	RouteDepartures DepartureMode = iota
+	// AnyLineDepartures is the earliest departure of the lines going in a direction
+	AnyLineDepartures
+	// BoardDepartures are the departures of all the lines leaving a stop
+	BoardDepartures
+)
+
+// Departure is a departure from the stop of an answer
+type Departure struct {
+	Line  tlgo.Line
+	Route tlgo.Route
+	Time  time.Time
+	// Destination and Waiting describe the departure on the screens,
+	// e.g. "Lutry, Corniche" and "5 min"
+	Destination string
+	Waiting     string
+	// Scheduled tells the time comes from the timetable or from the last
+	// real time departures
+	Scheduled bool
+}
+
+// NextDepartureAnswer tells the departures found for a NextDepartureQuery
+type NextDepartureAnswer struct {
+	Mode DepartureMode
+	Stop tlgo.Stop
+	// Line and Route are the ones of the told departures, they are not
+	// set for BoardDepartures.
+	Line  tlgo.Line
+	Route tlgo.Route
+	// Departures are the told departures. It is empty when all the
+	// departures have already been told.
+	Departures []Departure
+	// Offset is the number of departures told before these ones
+	Offset int
+	// After is the time of the last departure told before these ones
+	After time.Time
+	// At is the time after which the departures have been looked for
+	At time.Time
+	// Later tells if At is later than now
+	Later bool
+	// Scheduled tells the departures come from the timetable or from the
+	// last real time ones, the current ones not being available in time
+	Scheduled bool
+
+	// Utterance is the sentence answering the query
+	Utterance
+	// Title and Rows describe the departures for the devices with a screen
+	Title string
+	Rows  []string
+}
+
+// lineRoute is a line leaving a stop in one direction
+type lineRoute struct {
+	line  tlgo.Line
+	route tlgo.Route
+}
+
+// destination names the terminus precisely as many routes end in the same city
+func (r lineRoute) destination() string {
+	if r.route.CityDestinationStopName != "" {
+		return r.route.CityDestinationStopName
+	}
+	return r.route.CityDestination
+}
+
+// NextDepartures answers the next departures of a line in a direction.
+// The line is inferred from the stops when missing and all the lines and
+// directions leaving the stop are told when the direction is missing.
+func (a *Assistant) NextDepartures(ctx context.Context, q NextDepartureQuery) (NextDepartureAnswer, error) {
+
+	log.Printf("Next departure query...\n")
+	p := i18n.NewPrinter(q.Language)
+
+	// Negative offsets and counts fall back to the default ones
+	if q.Offset < 0 {
+		q.Offset = 0
+	}
+	if q.Count < 0 {
+		q.Count = 0
+	}
+
+	var answer NextDepartureAnswer
+	var err error
+	switch {
+	case q.Direction == "":
+		answer, err = a.departuresBoard(ctx, p, q)
+	case q.Line == "":
+		answer, err = a.anyLineDepartures(ctx, p, q)
+	default:
+		answer, err = a.routeDepartures(ctx, p, q)
+	}
+
+	// The user is warned the departures may be late
+	if err == nil && answer.Scheduled {
+		answer.Utterance = joinUtterances([]Utterance{say(p, i18n.ScheduledDepartures), answer.Utterance}, " ", false)
+	}
+	return answer, err
+}
+
+// departureTime returns the time after which the departures are looked
+// for and the time the departures are told relatively to.
+func (a *Assistant) departureTime(asked time.Time) (time.Time, time.Time) {
+
+	now := a.now().In(TimeZone)
+
+	// Past times are answered with the next departures
+	if asked.IsZero() || !isLater(asked, now) {
+		return now, now
+	}
+	return asked.In(TimeZone), now
+}
+
+// afterPhrase tells the asked time of the departures asked for later
+func afterPhrase(p i18n.Printer, at time.Time, now time.Time) Utterance {
+	if at.After(now) {
+		return say(p, i18n.AfterTime, clockUtterance(p, at))
+	}
+	return Utterance{}
+}
+
+func (a *Assistant) routeDepartures(ctx context.Context, p i18n.Printer, q NextDepartureQuery) (NextDepartureAnswer, error) {
+
+	line, err := a.resolveLine(p, q.Line)
+	if err != nil {
+		return NextDepartureAnswer{}, err
+	}
+
+	origin, err := a.originStop(p, q.Origin, q.Position, &line)
+	if err != nil {
+		return NextDepartureAnswer{}, err
+	}
+
+	// We ensure lines holds the start stop
+	if !servesStop(line, origin) {
+		return NextDepartureAnswer{}, failure(nil, say(p, i18n.LineNotAtStop, line.ShortName, origin.Name))
+	}
+
+	direction, err := a.resolveStop(p, DirectionStop, q.Direction)
+	if err != nil {
+		return NextDepartureAnswer{}, err
+	}
+
+	// Then we try to find a routes for the corresponding destination
+	routes, err := a.store.GetRoutesForLineID(line.ID)
+	if err != nil {
+		log.Printf("The routes has not been found in the store: %v\n", err)
+		return NextDepartureAnswer{}, failure(err, say(p, i18n.LineUnavailable, line.Name))
+	}
+
+	route, match := a.routeTowards(routes, origin, direction)
+	switch match {
+	case routeUpstream:
+		return NextDepartureAnswer{}, failure(nil, say(p, i18n.LineUpstream, line.ShortName, origin.Name, direction.Name))
+	case routeNotFound:
+		return NextDepartureAnswer{}, failure(nil, say(p, i18n.NoRoute, direction.Name, line.Name))
+	}
+
+	at, now := a.departureTime(q.At)
+	after := afterPhrase(p, at, now)
+
+	log.Printf("Asking next departure from %s to %s via %s after %s \n", origin.Name, route.CityDestinationStopName, line.ShortName, at)
+
+	journeys, scheduled, err := a.fetchDepartures(ctx, origin, route, line.ID, at)
+	if err != nil {
+		log.Println("TLAPI get schedules error:", err)
+		return NextDepartureAnswer{}, departuresFailure(ctx, p, err)
+	}
+
+	if len(journeys) < 1 {
+		return NextDepartureAnswer{}, failure(nil, say(p, i18n.NoDeparture, line.ShortName, route.CityDestination, after))
+	}
+
+	// The departures already told are skipped. They are compared to the
+	// minute as the waiting times are, the real time ones moving between
+	// two queries.
+	following := q.Offset > 0 || !q.After.IsZero()
+	if !q.After.IsZero() {
+		left := []tlgo.Journey{}
+		for _, journey := range journeys {
+			if at.Add(journey.WaitingTime).Truncate(time.Minute).After(q.After.Truncate(time.Minute)) {
+				left = append(left, journey)
+			}
+		}
+		journeys = left
+	}
+
+	answer := NextDepartureAnswer{
+		Mode:      RouteDepartures,
+		Stop:      origin,
+		Line:      line,
+		Route:     route,
+		Offset:    q.Offset,
+		After:     q.After,
+		At:        at,
+		Later:     at.After(now),
+		Scheduled: scheduled,
+	}
+
+	if q.Offset >= len(journeys) {
+		answer.Offset = len(journeys)
+		answer.Utterance = say(p, i18n.NoOtherDeparture, line.ShortName, route.CityDestination, after)
+		return answer, nil
+	}
+
+	count := q.Count
+	if count < 1 {
+		count = 1
+	}
+	if count > MaxDeparturesCount {
+		count = MaxDeparturesCount
+	}
+
+	selected := journeys[q.Offset:]
+	if len(selected) > count {
+		selected = selected[:count]
+	}
+
+	times := make([]string, len(selected))
+	departures := make([]time.Time, len(selected))
+	for i, journey := range selected {
+		departures[i] = at.Add(journey.WaitingTime)
+		times[i] = shortWaitingTime(p, departures[i], now)
+		answer.Departures = append(answer.Departures, Departure{
+			Line:        line,
+			Route:       route,
+			Time:        departures[i],
+			Destination: lineRoute{line: line, route: route}.destination(),
+			Waiting:     times[i],
+			Scheduled:   scheduled,
+		})
+	}
+
+	if len(departures) == 1 {
+		id := i18n.NextDeparture
+		if following {
+			id = i18n.FollowingDeparture
+		}
+		answer.Utterance = say(p, id, line.ShortName, route.CityDestination, after, waitingPhrase(p, departures[0], now), origin.Name)
+	} else {
+		id := i18n.NextDepartures
+		if following {
+			id = i18n.FollowingDepartures
+		}
+		answer.Utterance = say(p, id, len(departures), line.ShortName, route.CityDestination, after, origin.Name, waitingListPhrase(p, departures, now))
+	}
+
+	answer.Title = p.Sprintf(i18n.RouteTitle, line.ShortName, route.CityDestination)
+	answer.Rows = []string{fmt.Sprintf("%s : %s", origin.Name, strings.Join(times, ", "))}
+	return answer, nil
+}
+
+func (a *Assistant) anyLineDepartures(ctx context.Context, p i18n.Printer, q NextDepartureQuery) (NextDepartureAnswer, error) {
+
+	origin, err := a.originStop(p, q.Origin, q.Position, nil)
+	if err != nil {
+		return NextDepartureAnswer{}, err
+	}
+
+	direction, err := a.resolveStop(p, DirectionStop, q.Direction)
+	if err != nil {
+		return NextDepartureAnswer{}, err
+	}
+
+	candidates, match := a.routesTowards(origin, direction)
+	switch match {
+	case routeUpstream:
+		return NextDepartureAnswer{}, failure(nil, say(p, i18n.NoLineUpstream, origin.Name, direction.Name))
+	case routeNotFound:
+		return NextDepartureAnswer{}, failure(nil, say(p, i18n.NoDirectLine, origin.Name, direction.Name))
+	}
+
+	at, now := a.departureTime(q.At)
+	after := afterPhrase(p, at, now)
+
+	departures, err := a.mergedDepartures(ctx, origin, candidates, at)
+	if err != nil {
+		log.Println("TLAPI get schedules error:", err)
+		return NextDepartureAnswer{}, departuresFailure(ctx, p, err)
+	}
+
+	if len(departures) == 0 {
+		return NextDepartureAnswer{}, failure(nil, say(p, i18n.NoDepartureTowards, origin.Name, direction.Name, after))
+	}
+
+	earliest := departures[0]
+	destination := lineRoute{line: earliest.Line, route: earliest.Route}.destination()
+	earliest.Destination, earliest.Waiting = destination, shortWaitingTime(p, earliest.Time, now)
+
+	// Follow-ups go on with the line of the earliest departure
+	return NextDepartureAnswer{
+		Mode:       AnyLineDepartures,
+		Stop:       origin,
+		Line:       earliest.Line,
+		Route:      earliest.Route,
+		Departures: []Departure{earliest},
+		At:         at,
+		Later:      at.After(now),
+		Scheduled:  earliest.Scheduled,
+		Utterance:  say(p, i18n.AnyLineDeparture, origin.Name, direction.Name, after, earliest.Line.ShortName, destination, waitingPhrase(p, earliest.Time, now)),
+		Title:      p.Sprintf(i18n.RouteTitle, earliest.Line.ShortName, earliest.Route.CityDestination),
+		Rows:       []string{fmt.Sprintf("%s : %s", origin.Name, earliest.Waiting)},
+	}, nil
+}
+
+func (a *Assistant) departuresBoard(ctx context.Context, p i18n.Printer, q NextDepartureQuery) (NextDepartureAnswer, error) {
+
+	log.Printf("Departures board...\n")
+
+	var line *tlgo.Line
+	if q.Line != "" {
+		resolved, err := a.resolveLine(p, q.Line)
+		if err != nil {
+			return NextDepartureAnswer{}, err
+		}
+		line = &resolved
+	}
+
+	stop, err := a.originStop(p, q.Origin, q.Position, line)
+	if err != nil {
+		return NextDepartureAnswer{}, err
+	}
+
+	routes := a.boardRoutes(stop, line)
+	if len(routes) == 0 {
+		return NextDepartureAnswer{}, failure(nil, say(p, i18n.NoLineAtStop, stop.Name))
+	}
+
+	count := q.Count
+	if count < 1 {
+		count = boardDeparturesCount
+	}
+	if count > MaxDeparturesCount {
+		count = MaxDeparturesCount
+	}
+
+	at, now := a.departureTime(q.At)
+	after := afterPhrase(p, at, now)
+
+	departures, err := a.mergedDepartures(ctx, stop, routes, at)
+	if err != nil {
+		log.Println("TLAPI get schedules error:", err)
+		return NextDepartureAnswer{}, departuresFailure(ctx, p, err)
+	}
+
+	if len(departures) == 0 {
+		return NextDepartureAnswer{}, failure(nil, say(p, i18n.NoDepartureAtStop, stop.Name, after))
+	}
+	if len(departures) > count {
+		departures = departures[:count]
+	}
+
+	phrases := make([]Utterance, len(departures))
+	rows := make([]string, len(departures))
+	for i, departure := range departures {
+		destination := lineRoute{line: departure.Line, route: departure.Route}.destination()
+		departures[i].Destination, departures[i].Waiting = destination, shortWaitingTime(p, departure.Time, now)
+		phrases[i] = say(p, i18n.BoardDeparture, departure.Line.ShortName, destination, waitingPhrase(p, departure.Time, now))
+		rows[i] = fmt.Sprintf("%s → %s : %s", departure.Line.ShortName, destination, departures[i].Waiting)
+	}
+
+	return NextDepartureAnswer{
+		Mode:       BoardDepartures,
+		Stop:       stop,
+		Departures: departures,
+		At:         at,
+		Later:      at.After(now),
+		Scheduled:  hasScheduled(departures),
+		Utterance:  say(p, i18n.Board, stop.Name, after, joinUtterances(phrases, ", ", true)),
+		Title:      p.Sprintf(i18n.BoardTitle, stop.Name),
+		Rows:       rows,
+	}, nil
+}
+
+// fetchDepartures returns the real time departures of the route after
+// the provided time. When the real time ones are not available before the
+// deadline of the context, the departures of the timetable are returned,
+// or the last real time ones without timetable.
+func (a *Assistant) fetchDepartures(ctx context.Context, stop tlgo.Stop, route tlgo.Route, lineID string, at time.Time) ([]tlgo.Journey, bool, error) {
+
+	key := departuresKey{stopID: stop.ID, lineID: lineID, wayback: route.Wayback}
+
+	journeys, err := a.provider.StopDepartures(ctx, stop.ID, lineID, at, route.Wayback)
+	if err == nil {
+		a.lastKnown.remember(key, at, a.now(), journeys)
+	}
+	if err == nil || ctx.Err() != context.DeadlineExceeded {
+		return journeys, false, err
+	}
+
+	scheduled, scheduledErr := a.scheduledDepartures(stop, route, at)
+	if scheduledErr == nil && len(scheduled) > 0 {
+		return scheduled, true, nil
+	}
+
+	if known, hasKnown := a.lastKnown.recall(key, at, a.now()); hasKnown {
+		log.Printf("Telling the last known departures of the route %s\n", route.ID)
+		return known, true, nil
+	}
+
+	log.Printf("No timetable for the route %s: %v\n", route.ID, scheduledErr)
+	return nil, false, err
+}
+
+// scheduledDepartures returns the departures of the route after the
+// provided time from the sampled timetable, as the real time ones would be.
+func (a *Assistant) scheduledDepartures(stop tlgo.Stop, route tlgo.Route, at time.Time) ([]tlgo.Journey, error) {
+
+	trips, err := a.store.GetTripsForRouteID(route.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	at = at.In(TimeZone)
+	midnight := time.Date(at.Year(), at.Month(), at.Day(), 0, 0, 0, 0, TimeZone)
+
+	// The first departures of the next day follow the last ones of the day
+	waitingTimes := []time.Duration{}
+	for _, day := range []time.Time{midnight, midnight.AddDate(0, 0, 1)} {
+		for _, trip := range trips {
+			if !trip.RunsOn(day) {
+				continue
+			}
+			for _, stopTime := range trip.StopTimes {
+				departure := stopTime.On(day)
+				if stopTime.StopAreaName == stop.Name && !departure.Before(at) {
+					waitingTimes = append(waitingTimes, departure.Sub(at))
+				}
+			}
+		}
+	}
+
+	sort.Slice(waitingTimes, func(i, j int) bool {
+		return waitingTimes[i] < waitingTimes[j]
+	})
+
+	journeys := make([]tlgo.Journey, len(waitingTimes))
+	for i, waitingTime := range waitingTimes {
+		journeys[i] = tlgo.Journey{WaitingTime: waitingTime}
+	}
+	return journeys, nil
+}
+
+// departuresFailure tells the departures could not be fetched, without
+// blaming an internal error when the real time ones were only too slow.
+func departuresFailure(ctx context.Context, p i18n.Printer, err error) error {
+	if ctx.Err() == context.DeadlineExceeded {
+		return failure(err, say(p, i18n.DeparturesUnavailable))
+	}
+	return failure(err, say(p, i18n.InternalError))
+}
+
+// hasScheduled tells if one of the departures comes from the timetable
+func hasScheduled(departures []Departure) bool {
+	for _, departure := range departures {
+		if departure.Scheduled {
+			return true
+		}
+	}
+	return false
+}
+
+// mergedDepartures fetches the departures of all the routes and merges
+// them by time.
+func (a *Assistant) mergedDepartures(ctx context.Context, stop tlgo.Stop, routes []lineRoute, at time.Time) ([]Departure, error) {
+
+	journeys := make([][]tlgo.Journey, len(routes))
+	scheduled := make([]bool, len(routes))
+	errs := make([]error, len(routes))
+
+	var wg sync.WaitGroup
+	for i, route := range routes {
+		wg.Add(1)
+		go func(i int, route lineRoute) {
+			defer wg.Done()
+			journeys[i], scheduled[i], errs[i] = a.fetchDepartures(ctx, stop, route.route, route.line.ID, at)
+		}(i, route)
+	}
+	wg.Wait()
+
+	departures := []Departure{}
+	failures := 0
+	for i, route := range routes {
+		if errs[i] != nil {
+			log.Printf("Can not get the departures of line %s to %s: %v\n", route.line.ShortName, route.route.CityDestination, errs[i])
+			failures++
+			continue
+		}
+		for _, journey := range journeys[i] {
+			departures = append(departures, Departure{Line: route.line, Route: route.route, Time: at.Add(journey.WaitingTime), Scheduled: scheduled[i]})
+		}
+	}
+
+	// Partial boards are still worth telling
+	if failures == len(routes) {
+		return nil, errs[0]
+	}
+
+	sort.SliceStable(departures, func(i, j int) bool {
+		return departures[i].Time.Before(departures[j].Time)
+	})
+	return departures, nil
+}
@@ -0,0 +1,420 @@
+package assistant
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gophersch/tlgo"
+	"github.com/yageek/tl-ai/dataprovider"
+	"github.com/yageek/tl-ai/i18n"
+	"github.com/yageek/tl-ai/realtime"
+	"github.com/yageek/tl-ai/search"
+	"github.com/yageek/tl-ai/storage"
+)
+
+// stallingProvider answers the departures until it is stalled, after which
+// it only returns once the context is done.
+type stallingProvider struct {
+	journeys []tlgo.Journey
+	stalled  bool
+}
+
+func (p *stallingProvider) StopDepartures(ctx context.Context, stopID string, lineID string, at time.Time, wayback bool) ([]tlgo.Journey, error) {
+	if !p.stalled {
+		return p.journeys, nil
+	}
+	<-ctx.Done()
+	return nil, ctx.Err()
+}
+
+// testStore holds line 2 going from Ouchy to Gare through Flon and back,
+// whose trips towards Gare run at 8:00 and 8:30 on weekdays and at 9:00 on
+// Sundays, and line 3 going from Flon to Gare.
+func testStore(withTrips bool) *storage.Store {
+
+	stops := func(names ...string) []tlgo.StopRouteDetails {
+		details := make([]tlgo.StopRouteDetails, len(names))
+		for i, name := range names {
+			details[i] = tlgo.StopRouteDetails{StopAreaName: name}
+		}
+		return details
+	}
+
+	data := dataprovider.APIRawData{
+		Stops: []tlgo.Stop{
+			{ID: "ouchy", Name: "Ouchy", LinesShortName: []string{"2"}},
+			{ID: "flon", Name: "Flon", LinesShortName: []string{"2", "3"}},
+			{ID: "gare", Name: "Gare", LinesShortName: []string{"2", "3"}},
+		},
+		Lines: []tlgo.Line{
+			{ID: "L2", Name: "Ligne 2", ShortName: "2"},
+			{ID: "L3", Name: "Ligne 3", ShortName: "3"},
+		},
+		RoutesByLineID: map[string][]tlgo.Route{
+			"L2": {
+				{ID: "r2", CityDestination: "Gare", CityDestinationStopName: "Gare", MainRoute: true},
+				{ID: "r2b", CityDestination: "Ouchy", CityDestinationStopName: "Ouchy", MainRoute: true, Wayback: true},
+			},
+			"L3": {{ID: "r3", CityDestination: "Gare", CityDestinationStopName: "Gare", MainRoute: true}},
+		},
+		RoutesDetailsByRouteID: map[string]tlgo.RouteDetails{
+			"r2":  {LineID: "L2", Stops: stops("Ouchy", "Flon", "Gare")},
+			"r2b": {LineID: "L2", Wayback: true, Stops: stops("Gare", "Flon", "Ouchy")},
+			"r3":  {LineID: "L3", Stops: stops("Flon", "Gare")},
+		},
+	}
+
+	if withTrips {
+		trip := func(day dataprovider.ServiceDay, start time.Duration) dataprovider.Trip {
+			return dataprovider.Trip{Day: day, StopTimes: []dataprovider.StopTime{
+				{StopAreaName: "Ouchy", Time: start},
+				{StopAreaName: "Flon", Time: start + 5*time.Minute},
+				{StopAreaName: "Gare", Time: start + 10*time.Minute},
+			}}
+		}
+		data.TripsByRouteID = map[string][]dataprovider.Trip{"r2": {
+			trip(dataprovider.Weekdays, 8*time.Hour),
+			trip(dataprovider.Weekdays, 8*time.Hour+30*time.Minute),
+			trip(dataprovider.Sundays, 9*time.Hour),
+		}}
+	}
+	return storage.NewStore(data)
+}
+
+func newTestAssistant(t *testing.T, provider *stallingProvider, withTrips bool, now time.Time) *Assistant {
+	t.Helper()
+
+	store := testStore(withTrips)
+	graph, err := search.NewBFSWithOptions(*store, search.GraphOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	a := New(store, graph, nil, provider)
+	a.now = func() time.Time { return now }
+	return a
+}
+
+var flonToGare = NextDepartureQuery{Line: "2", Origin: "Flon", Direction: "Gare", Count: 2, Language: i18n.English}
+
+func stallingContext() (context.Context, context.CancelFunc) {
+	return context.WithTimeout(context.Background(), 20*time.Millisecond)
+}
+
+func TestStalledDeparturesAreUnavailable(t *testing.T) {
+
+	now := time.Date(2026, 10, 19, 7, 50, 0, 0, TimeZone)
+	a := newTestAssistant(t, &stallingProvider{stalled: true}, false, now)
+
+	ctx, cancel := stallingContext()
+	defer cancel()
+
+	_, err := a.NextDepartures(ctx, flonToGare)
+	want := i18n.NewPrinter(i18n.English).Sprintf(i18n.DeparturesUnavailable)
+	if speech := Speech(err, i18n.English); speech.Text != want {
+		t.Errorf("expected %q, got %q (%v)", want, speech.Text, err)
+	}
+}
+
+func TestStalledDeparturesFromTheTimetable(t *testing.T) {
+
+	tests := []struct {
+		name string
+		now  time.Time
+		want []time.Time
+	}{
+		{"monday", time.Date(2026, 10, 19, 7, 50, 0, 0, TimeZone), []time.Time{
+			time.Date(2026, 10, 19, 8, 5, 0, 0, TimeZone),
+			time.Date(2026, 10, 19, 8, 35, 0, 0, TimeZone),
+		}},
+		// The saturday has no trip, the sunday ones follow
+		{"saturday", time.Date(2026, 10, 24, 7, 50, 0, 0, TimeZone), []time.Time{
+			time.Date(2026, 10, 25, 9, 5, 0, 0, TimeZone),
+		}},
+		// The clock goes back on that sunday
+		{"sunday", time.Date(2026, 10, 25, 7, 50, 0, 0, TimeZone), []time.Time{
+			time.Date(2026, 10, 25, 9, 5, 0, 0, TimeZone),
+			time.Date(2026, 10, 26, 8, 5, 0, 0, TimeZone),
+		}},
+	}
+
+	for _, test := range tests {
+
+		a := newTestAssistant(t, &stallingProvider{stalled: true}, true, test.now)
+		ctx, cancel := stallingContext()
+		answer, err := a.NextDepartures(ctx, flonToGare)
+		cancel()
+		if err != nil {
+			t.Errorf("%s: %v", test.name, err)
+			continue
+		}
+
+		if !answer.Scheduled {
+			t.Errorf("%s: the departures should be told as scheduled", test.name)
+		}
+		if len(answer.Departures) != len(test.want) {
+			t.Errorf("%s: expected %d departures, got %d", test.name, len(test.want), len(answer.Departures))
+			continue
+		}
+		for i, departure := range answer.Departures {
+			if !departure.Time.Equal(test.want[i]) {
+				t.Errorf("%s: expected a departure at %s, got %s", test.name, test.want[i], departure.Time)
+			}
+		}
+	}
+}
+
+func TestStalledDeparturesFromTheLastKnownOnes(t *testing.T) {
+
+	now := time.Date(2026, 10, 19, 7, 50, 0, 0, TimeZone)
+	provider := &stallingProvider{journeys: []tlgo.Journey{{WaitingTime: 3 * time.Minute}, {WaitingTime: 10 * time.Minute}}}
+	a := newTestAssistant(t, provider, false, now)
+
+	answer, err := a.NextDepartures(context.Background(), flonToGare)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if answer.Scheduled {
+		t.Errorf("the real time departures should not be told as scheduled")
+	}
+
+	// Five minutes later, the departure in three minutes has left
+	provider.stalled = true
+	a.now = func() time.Time { return now.Add(5 * time.Minute) }
+
+	ctx, cancel := stallingContext()
+	answer, err = a.NextDepartures(ctx, flonToGare)
+	cancel()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(answer.Departures) != 1 || !answer.Departures[0].Time.Equal(now.Add(10*time.Minute)) {
+		t.Fatalf("expected the departure at %s, got %+v", now.Add(10*time.Minute), answer.Departures)
+	}
+	warning := i18n.NewPrinter(i18n.English).Sprintf(i18n.ScheduledDepartures)
+	if !answer.Scheduled || !strings.HasPrefix(answer.Text, warning) {
+		t.Errorf("expected the user to be warned, got %q", answer.Text)
+	}
+
+	// The departures remembered for too long are not told anymore
+	a.now = func() time.Time { return now.Add(lastKnownMaxAge + time.Minute) }
+	ctx, cancel = stallingContext()
+	defer cancel()
+	if _, err := a.NextDepartures(ctx, flonToGare); err == nil {
+		t.Errorf("expected the departures to be unavailable")
+	}
+}
+
+// fixtureAssistant answers with the departures of the fixtures
+func fixtureAssistant(t *testing.T, fixtures string, now time.Time) *Assistant {
+	t.Helper()
+
+	provider, err := realtime.LoadFixtures(strings.NewReader(fixtures))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	store := testStore(false)
+	graph, err := search.NewBFSWithOptions(*store, search.GraphOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	a := New(store, graph, nil, provider)
+	a.now = func() time.Time { return now }
+	return a
+}
+
+func TestSeveralDepartures(t *testing.T) {
+
+	now := time.Date(2026, 10, 19, 7, 50, 0, 0, TimeZone)
+	a := fixtureAssistant(t, `[{"stopId": "flon", "lineId": "L2", "waitingTimes": ["2m", "5m", "9m", "14m", "20m", "27m", "35m"]}]`, now)
+
+	tests := []struct {
+		name   string
+		offset int
+		count  int
+		want   []time.Duration
+	}{
+		{"next", 0, 0, []time.Duration{2 * time.Minute}},
+		{"three next", 0, 3, []time.Duration{2 * time.Minute, 5 * time.Minute, 9 * time.Minute}},
+		{"at most five", 0, 10, []time.Duration{2 * time.Minute, 5 * time.Minute, 9 * time.Minute, 14 * time.Minute, 20 * time.Minute}},
+		{"two following", 3, 2, []time.Duration{14 * time.Minute, 20 * time.Minute}},
+		{"last ones", 5, 4, []time.Duration{27 * time.Minute, 35 * time.Minute}},
+		{"none left", 7, 1, []time.Duration{}},
+		{"negative offset", -2, 1, []time.Duration{2 * time.Minute}},
+		{"negative count", 1, -3, []time.Duration{5 * time.Minute}},
+	}
+
+	for _, test := range tests {
+		q := flonToGare
+		q.Offset, q.Count = test.offset, test.count
+
+		answer, err := a.NextDepartures(context.Background(), q)
+		if err != nil {
+			t.Errorf("%s: %v", test.name, err)
+			continue
+		}
+
+		if len(answer.Departures) != len(test.want) {
+			t.Errorf("%s: expected %d departures, got %d", test.name, len(test.want), len(answer.Departures))
+			continue
+		}
+		for i, departure := range answer.Departures {
+			if want := now.Add(test.want[i]); !departure.Time.Equal(want) {
+				t.Errorf("%s: expected a departure at %s, got %s", test.name, want, departure.Time)
+			}
+		}
+	}
+}
+
+func TestFollowingDepartures(t *testing.T) {
+
+	now := time.Date(2026, 10, 19, 7, 50, 0, 0, TimeZone)
+	a := fixtureAssistant(t, `[{"stopId": "flon", "lineId": "L2", "waitingTimes": ["2m", "5m", "9m", "14m"]}]`, now)
+
+	tests := []struct {
+		name  string
+		after time.Duration
+		want  []time.Duration
+	}{
+		{"after the first one", 2 * time.Minute, []time.Duration{5 * time.Minute, 9 * time.Minute}},
+		{"in the same minute", 5*time.Minute + 20*time.Second, []time.Duration{9 * time.Minute, 14 * time.Minute}},
+		{"none left", 14 * time.Minute, []time.Duration{}},
+	}
+
+	for _, test := range tests {
+		q := flonToGare
+		q.After = now.Add(test.after)
+
+		answer, err := a.NextDepartures(context.Background(), q)
+		if err != nil {
+			t.Errorf("%s: %v", test.name, err)
+			continue
+		}
+
+		if len(answer.Departures) != len(test.want) {
+			t.Errorf("%s: expected %d departures, got %d", test.name, len(test.want), len(answer.Departures))
+			continue
+		}
+		for i, departure := range answer.Departures {
+			if want := now.Add(test.want[i]); !departure.Time.Equal(want) {
+				t.Errorf("%s: expected a departure at %s, got %s", test.name, want, departure.Time)
+			}
+		}
+		if len(test.want) > 0 && !strings.HasPrefix(answer.Text, "The 2 following buses") {
+			t.Errorf("%s: expected the following departures, got %q", test.name, answer.Text)
+		}
+		if len(test.want) == 0 && !strings.HasPrefix(answer.Text, "I have no other departure") {
+			t.Errorf("%s: expected no other departure, got %q", test.name, answer.Text)
+		}
+	}
+}
+
+// boardFixtures are the departures of the lines leaving Flon
+const boardFixtures = `[
+	{"stopId": "flon", "lineId": "L2", "wayback": false, "waitingTimes": ["2m", "9m"]},
+	{"stopId": "flon", "lineId": "L2", "wayback": true, "waitingTimes": ["4m"]},
+	{"stopId": "flon", "lineId": "L3", "wayback": false, "waitingTimes": ["1m", "6m"]}
+]`
+
+func TestDeparturesBoard(t *testing.T) {
+
+	now := time.Date(2026, 10, 19, 7, 50, 0, 0, TimeZone)
+
+	type departure struct {
+		line        string
+		destination string
+		waiting     time.Duration
+	}
+
+	tests := []struct {
+		name     string
+		fixtures string
+		line     string
+		want     []departure
+	}{
+		{"all the lines", boardFixtures, "", []departure{
+			{"3", "Gare", time.Minute},
+			{"2", "Gare", 2 * time.Minute},
+			{"2", "Ouchy", 4 * time.Minute},
+			{"3", "Gare", 6 * time.Minute},
+		}},
+		{"a single line", boardFixtures, "2", []departure{
+			{"2", "Gare", 2 * time.Minute},
+			{"2", "Ouchy", 4 * time.Minute},
+			{"2", "Gare", 9 * time.Minute},
+		}},
+		// The lines whose departures are unavailable are left out
+		{"partial", `[{"stopId": "flon", "lineId": "L3", "waitingTimes": ["1m"]}]`, "", []departure{
+			{"3", "Gare", time.Minute},
+		}},
+	}
+
+	for _, test := range tests {
+		a := fixtureAssistant(t, test.fixtures, now)
+
+		answer, err := a.NextDepartures(context.Background(), NextDepartureQuery{Line: test.line, Origin: "Flon", Language: i18n.English})
+		if err != nil {
+			t.Errorf("%s: %v", test.name, err)
+			continue
+		}
+		if answer.Mode != BoardDepartures {
+			t.Errorf("%s: expected a board, got mode %d", test.name, answer.Mode)
+		}
+
+		if len(answer.Departures) != len(test.want) {
+			t.Errorf("%s: expected %d departures, got %d", test.name, len(test.want), len(answer.Departures))
+			continue
+		}
+		for i, got := range answer.Departures {
+			want := test.want[i]
+			if got.Line.ShortName != want.line || got.Destination != want.destination || !got.Time.Equal(now.Add(want.waiting)) {
+				t.Errorf("%s: expected line %s to %s at %s, got line %s to %s at %s", test.name, want.line, want.destination, now.Add(want.waiting), got.Line.ShortName, got.Destination, got.Time)
+			}
+		}
+	}
+
+	a := fixtureAssistant(t, `[]`, now)
+	if _, err := a.NextDepartures(context.Background(), NextDepartureQuery{Origin: "Flon", Language: i18n.English}); err == nil {
+		t.Errorf("expected the departures to be unavailable")
+	}
+}
+
+func TestDeparturesLater(t *testing.T) {
+
+	now := time.Date(2026, 10, 19, 10, 0, 0, 0, TimeZone)
+	a := fixtureAssistant(t, `[{"stopId": "flon", "lineId": "L2", "waitingTimes": ["7m", "75m"]}]`, now)
+
+	tests := []struct {
+		name  string
+		at    time.Time
+		later bool
+		want  string
+	}{
+		{"later", now.Add(8 * time.Hour), true, "The next bus 2 towards Gare after 18:00 leaves at 18:07 from Flon"},
+		// The departures asked in the past are the next ones
+		{"past", now.Add(-time.Hour), false, "The next bus 2 towards Gare leaves in 7 minutes from Flon"},
+		{"now", now.Add(30 * time.Second), false, "The next bus 2 towards Gare leaves in 7 minutes from Flon"},
+	}
+
+	for _, test := range tests {
+		q := flonToGare
+		q.Count, q.At = 1, test.at
+
+		answer, err := a.NextDepartures(context.Background(), q)
+		if err != nil {
+			t.Errorf("%s: %v", test.name, err)
+			continue
+		}
+		if answer.Later != test.later {
+			t.Errorf("%s: expected later to be %v", test.name, test.later)
+		}
+		if answer.Text != test.want {
+			t.Errorf("%s: expected %q, got %q", test.name, test.want, answer.Text)
+		}
+	}
+}
@@ -0,0 +1,107 @@
+package assistant
+
+import (
+	"log"
+	"time"
+
+	"github.com/yageek/tl-ai/i18n"
+	"github.com/yageek/tl-ai/search"
+)
+
+// JourneyQuery asks how to go from a stop to another
+type JourneyQuery struct {
+	// Origin and Destination are the spoken stop names
+	Origin      string
+	Destination string
+	// Language is the language of the answer
+	Language i18n.Language
+}
+
+// JourneyAnswer tells the itinerary found for a JourneyQuery
+type JourneyAnswer struct {
+	Itinerary search.Itinerary
+	// Utterance is the sentence answering the query
+	Utterance
+}
+
+// Journey answers the itinerary between two stops
+func (a *Assistant) Journey(q JourneyQuery) (JourneyAnswer, error) {
+
+	log.Printf("Journey query...\n")
+	p := i18n.NewPrinter(q.Language)
+
+	origin, err := a.resolveStop(p, OriginStop, q.Origin)
+	if err != nil {
+		return JourneyAnswer{}, err
+	}
+
+	destination, err := a.resolveStop(p, DestinationStop, q.Destination)
+	if err != nil {
+		return JourneyAnswer{}, err
+	}
+
+	if origin.Name == destination.Name {
+		return JourneyAnswer{}, failure(nil, say(p, i18n.AlreadyThere, origin.Name))
+	}
+
+	now := a.now().In(TimeZone)
+	itinerary, err := a.findItinerary(origin.Name, destination.Name, now)
+	if err == search.ErrNoPathFound || (err == nil && len(itinerary.Legs) == 0) {
+		return JourneyAnswer{}, failure(err, say(p, i18n.NoItinerary, origin.Name, destination.Name))
+	} else if err != nil {
+		log.Printf("The journey search failed: %v\n", err)
+		return JourneyAnswer{}, failure(err, say(p, i18n.InternalError))
+	}
+
+	return JourneyAnswer{Itinerary: itinerary, Utterance: journeySentence(p, itinerary, now)}, nil
+}
+
+// findItinerary looks for the earliest arrival on the timetable when there
+// is one. The itineraries not running by tomorrow, or only made on foot,
+// are looked for on the graph of the routes.
+func (a *Assistant) findItinerary(origin string, destination string, now time.Time) (search.Itinerary, error) {
+
+	if a.router != nil {
+		itinerary, err := a.router.FindEarliestArrivalPath(origin, destination, now)
+		if err == nil {
+			return itinerary, nil
+		}
+		log.Printf("No itinerary on the timetable from %s to %s: %v\n", origin, destination, err)
+	}
+	return a.graph.FindStopToStopPath(origin, destination)
+}
+
+// journeySentence tells the legs of the itinerary, the first ride being
+// told for tomorrow when nothing runs anymore today.
+func journeySentence(p i18n.Printer, itinerary search.Itinerary, now time.Time) Utterance {
+
+	parts := make([]Utterance, len(itinerary.Legs))
+	hasRidden := false
+	for i, leg := range itinerary.Legs {
+		if leg.Walk {
+			minutes := int(leg.WalkDuration.Minutes() + 0.5)
+			if minutes < 1 {
+				minutes = 1
+			}
+			parts[i] = say(p, i18n.WalkLeg, minutesPhrase(p, minutes), leg.Alight.Name)
+		} else if !hasRidden && !leg.Departure.IsZero() && !sameDay(leg.Departure, now) {
+			parts[i] = say(p, i18n.TomorrowRideLeg, leg.Line.ShortName, clockUtterance(p, leg.Departure), leg.Direction, leg.Alight.Name)
+		} else if !hasRidden && !leg.Departure.IsZero() {
+			parts[i] = say(p, i18n.TimedFirstRideLeg, leg.Line.ShortName, clockUtterance(p, leg.Departure), leg.Direction, leg.Alight.Name)
+		} else if !hasRidden {
+			parts[i] = say(p, i18n.FirstRideLeg, leg.Line.ShortName, leg.Direction, leg.Alight.Name)
+		} else {
+			parts[i] = say(p, i18n.TransferLeg, leg.Line.ShortName, leg.Direction, leg.Alight.Name)
+		}
+		hasRidden = hasRidden || !leg.Walk
+	}
+
+	return say(p, i18n.Journey, itinerary.Origin.Name, joinUtterances(parts, p.Sprintf(i18n.ThenSeparator), false))
+}
+
+// sameDay tells if the times are on the same day in Lausanne
+func sameDay(t time.Time, other time.Time) bool {
+	year, month, day := t.In(TimeZone).Date()
+	otherYear, otherMonth, otherDay := other.In(TimeZone).Date()
+	return year == otherYear && month == otherMonth && day == otherDay
+}
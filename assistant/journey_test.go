@@ -0,0 +1,55 @@
+package assistant
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/yageek/tl-ai/i18n"
+	"github.com/yageek/tl-ai/search"
+)
+
+func TestJourneyOnTheTimetable(t *testing.T) {
+
+	store := testStore(true)
+	graph, err := search.NewBFSWithOptions(*store, search.GraphOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	router, err := search.NewRouter(*store, search.DefaultRouterOptions)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	a := New(store, graph, router, &stallingProvider{})
+	query := JourneyQuery{Origin: "Ouchy", Destination: "Gare", Language: i18n.English}
+
+	a.now = func() time.Time { return time.Date(2026, 10, 19, 8, 10, 0, 0, TimeZone) }
+	answer, err := a.Journey(query)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "take line 2 at 8:30 towards Gare to Gare"; !strings.Contains(answer.Text, want) {
+		t.Errorf("expected %q in %q", want, answer.Text)
+	}
+
+	// Without trip on saturday, the one of sunday is told
+	a.now = func() time.Time { return time.Date(2026, 10, 24, 8, 10, 0, 0, TimeZone) }
+	answer, err = a.Journey(query)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "take line 2 tomorrow at 9:00 towards Gare to Gare"; !strings.Contains(answer.Text, want) {
+		t.Errorf("expected %q in %q", want, answer.Text)
+	}
+
+	// Without trip left on friday nor on saturday, the itinerary is found on the routes
+	a.now = func() time.Time { return time.Date(2026, 10, 23, 8, 40, 0, 0, TimeZone) }
+	answer, err = a.Journey(query)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "take line 2 towards Gare to Gare"; !strings.Contains(answer.Text, want) {
+		t.Errorf("expected %q in %q", want, answer.Text)
+	}
+}
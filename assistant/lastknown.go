@@ -0,0 +1,74 @@
+package assistant
+
+import (
+	"sync"
+	"time"
+
+	"github.com/gophersch/tlgo"
+)
+
+// lastKnownMaxAge is the age after which the last real time departures
+// are too far from the real ones to be told
+const lastKnownMaxAge = 20 * time.Minute
+
+type departuresKey struct {
+	stopID  string
+	lineID  string
+	wayback bool
+}
+
+// knownDepartures are real time departures and the time they were fetched at
+type knownDepartures struct {
+	times     []time.Time
+	fetchedAt time.Time
+}
+
+// lastKnownDepartures keeps the last real time departures of each line at
+// each stop, in both directions. There is at most an entry for each of
+// them, so the size is bounded by the network.
+type lastKnownDepartures struct {
+	mu         sync.Mutex
+	departures map[departuresKey]knownDepartures
+}
+
+func newLastKnownDepartures() *lastKnownDepartures {
+	return &lastKnownDepartures{departures: map[departuresKey]knownDepartures{}}
+}
+
+// remember keeps the departures fetched now for the provided time
+func (l *lastKnownDepartures) remember(key departuresKey, at time.Time, now time.Time, journeys []tlgo.Journey) {
+
+	if len(journeys) == 0 {
+		return
+	}
+
+	times := make([]time.Time, len(journeys))
+	for i, journey := range journeys {
+		times[i] = at.Add(journey.WaitingTime)
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.departures[key] = knownDepartures{times: times, fetchedAt: now}
+}
+
+// recall returns the remembered departures after the provided time, as the
+// provider would, when they are recent enough.
+func (l *lastKnownDepartures) recall(key departuresKey, at time.Time, now time.Time) ([]tlgo.Journey, bool) {
+
+	l.mu.Lock()
+	known, hasKnown := l.departures[key]
+	l.mu.Unlock()
+
+	if !hasKnown || now.Sub(known.fetchedAt) > lastKnownMaxAge {
+		return nil, false
+	}
+
+	journeys := []tlgo.Journey{}
+	for _, departure := range known.times {
+		if !departure.Before(at) {
+			journeys = append(journeys, tlgo.Journey{WaitingTime: departure.Sub(at)})
+		}
+	}
+	return journeys, len(journeys) > 0
+}
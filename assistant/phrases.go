@@ -0,0 +1,115 @@
+package assistant
+
+import (
+	"log"
+	"strconv"
+	"time"
+
+	"github.com/yageek/tl-ai/i18n"
+)
+
+const (
+	// imminentDelay is the delay under which a departure is told
+	// relatively to now ("dans 5 minutes") rather than with its time
+	imminentDelay = time.Hour
+	// nowDelay is the delay under which a departure leaves "maintenant"
+	nowDelay = 30 * time.Second
+	// oneMinuteDelay is the delay under which a departure leaves "dans une minute"
+	oneMinuteDelay = 90 * time.Second
+	// laterDelay is the delay after which an asked time is considered
+	// as later than now
+	laterDelay = time.Minute
+)
+
+// TimeZone is the time zone of the network and of the users
+var TimeZone = loadTimeZone()
+
+func loadTimeZone() *time.Location {
+	location, err := time.LoadLocation("Europe/Zurich")
+	if err != nil {
+		log.Printf("Can not load the Europe/Zurich time zone, falling back to CET: %v\n", err)
+		return time.FixedZone("CET", 3600)
+	}
+	return location
+}
+
+func isImminent(departure time.Time, now time.Time) bool {
+	return departure.Sub(now) < imminentDelay
+}
+
+func isLater(at time.Time, now time.Time) bool {
+	return at.Sub(now) > laterDelay
+}
+
+// waitingMinutes rounds the time to wait for a departure to the minute
+func waitingMinutes(departure time.Time, now time.Time) int {
+	return int(departure.Sub(now).Minutes() + 0.5)
+}
+
+// minutesPhrase tells a count of minutes, e.g. "5 minutes"
+func minutesPhrase(p i18n.Printer, minutes int) Utterance {
+	return sayPlural(p, i18n.Minutes, minutes, strconv.Itoa(minutes))
+}
+
+// waitingPhrase tells naturally when a departure leaves: "maintenant",
+// "dans une minute", "dans 5 minutes" and "à 18h07" beyond an hour.
+func waitingPhrase(p i18n.Printer, departure time.Time, now time.Time) Utterance {
+
+	switch waiting := departure.Sub(now); {
+	case waiting < nowDelay:
+		return say(p, i18n.Now)
+	case waiting < oneMinuteDelay:
+		return say(p, i18n.InOneMinute)
+	case waiting < imminentDelay:
+		return say(p, i18n.In, minutesPhrase(p, waitingMinutes(departure, now)))
+	}
+	return say(p, i18n.AtClock, clockUtterance(p, departure))
+}
+
+// waitingListPhrase groups several departures in a single phrase such
+// as "dans 2 minutes, puis dans 9 et 17 minutes" or "à 18h07, puis à
+// 18h15 et 18h22" when the first one is not imminent. The departures
+// beyond the hour are told with their time when the list mixes both, as
+// in "dans 40 minutes, puis dans 55 minutes et à 18h32".
+func waitingListPhrase(p i18n.Printer, departures []time.Time, now time.Time) Utterance {
+
+	first := waitingPhrase(p, departures[0], now)
+	if len(departures) == 1 {
+		return first
+	}
+
+	rest := departures[1:]
+	var then Utterance
+	switch {
+	case isImminent(rest[len(rest)-1], now):
+		minutes := make([]Utterance, len(rest))
+		for i, departure := range rest {
+			minutes[i] = Plain(strconv.Itoa(waitingMinutes(departure, now)))
+		}
+		last := waitingMinutes(rest[len(rest)-1], now)
+		then = say(p, i18n.Then, say(p, i18n.In, sayPlural(p, i18n.Minutes, last, listUtterance(p, i18n.And, minutes))))
+	case !isImminent(rest[0], now):
+		times := make([]Utterance, len(rest))
+		for i, departure := range rest {
+			times[i] = clockUtterance(p, departure)
+		}
+		then = say(p, i18n.Then, say(p, i18n.AtClock, listUtterance(p, i18n.And, times)))
+	default:
+		phrases := make([]Utterance, len(rest))
+		for i, departure := range rest {
+			phrases[i] = waitingPhrase(p, departure, now)
+		}
+		then = say(p, i18n.Then, listUtterance(p, i18n.And, phrases))
+	}
+
+	return Utterance{Text: first.Text + then.Text, SSML: first.SSML + departuresPause + then.SSML}
+}
+
+// shortWaitingTime is the written counterpart of waitingPhrase, e.g.
+// "5 min" or "18h07"
+func shortWaitingTime(p i18n.Printer, departure time.Time, now time.Time) string {
+	if isImminent(departure, now) {
+		return p.Sprintf(i18n.ShortMinutes, waitingMinutes(departure, now))
+	}
+	return clockUtterance(p, departure).Text
+}
@@ -0,0 +1,77 @@
+package assistant
+
+import (
+	"testing"
+	"time"
+
+	"github.com/yageek/tl-ai/i18n"
+)
+
+func TestWaitingListPhrase(t *testing.T) {
+
+	p := i18n.NewPrinter(i18n.French)
+	now := time.Date(2026, 10, 18, 13, 20, 0, 0, TimeZone)
+
+	tests := []struct {
+		waiting []time.Duration
+		want    string
+	}{
+		{[]time.Duration{2 * time.Minute}, "dans 2 minutes"},
+		{[]time.Duration{2 * time.Minute, 9 * time.Minute, 17 * time.Minute}, "dans 2 minutes, puis dans 9 et 17 minutes"},
+		{[]time.Duration{2 * time.Minute, 9 * time.Minute, 75 * time.Minute}, "dans 2 minutes, puis dans 9 minutes et à 14h35"},
+		{[]time.Duration{2 * time.Minute, 72 * time.Minute, 80 * time.Minute}, "dans 2 minutes, puis à 14h32 et 14h40"},
+		{[]time.Duration{72 * time.Minute, 80 * time.Minute}, "à 14h32, puis à 14h40"},
+	}
+
+	for _, test := range tests {
+		departures := make([]time.Time, len(test.waiting))
+		for i, waiting := range test.waiting {
+			departures[i] = now.Add(waiting)
+		}
+		if phrase := waitingListPhrase(p, departures, now); phrase.Text != test.want {
+			t.Errorf("expected %q, got %q", test.want, phrase.Text)
+		}
+	}
+}
+
+func TestWaitingPhrase(t *testing.T) {
+
+	p := i18n.NewPrinter(i18n.French)
+	now := time.Date(2026, 10, 18, 13, 20, 0, 0, TimeZone)
+
+	tests := []struct {
+		waiting time.Duration
+		text    string
+		ssml    string
+	}{
+		{10 * time.Second, "maintenant", "maintenant"},
+		{50 * time.Second, "dans une minute", "dans une minute"},
+		{80 * time.Second, "dans une minute", "dans une minute"},
+		{5*time.Minute + 40*time.Second, "dans 6 minutes", "dans 6 minutes"},
+		{75 * time.Minute, "à 14h35", `à <say-as interpret-as="time" format="hms24">14:35</say-as>`},
+	}
+
+	for _, test := range tests {
+		phrase := waitingPhrase(p, now.Add(test.waiting), now)
+		if phrase.Text != test.text || phrase.SSML != test.ssml {
+			t.Errorf("%s: expected %q and %q, got %q and %q", test.waiting, test.text, test.ssml, phrase.Text, phrase.SSML)
+		}
+	}
+}
+
+func TestWaitingListSpeech(t *testing.T) {
+
+	p := i18n.NewPrinter(i18n.French)
+	now := time.Date(2026, 10, 18, 13, 20, 0, 0, TimeZone)
+
+	phrase := waitingListPhrase(p, []time.Time{now.Add(2 * time.Minute), now.Add(72 * time.Minute)}, now)
+	want := `dans 2 minutes` + departuresPause + `, puis à <say-as interpret-as="time" format="hms24">14:32</say-as>`
+	if phrase.SSML != want {
+		t.Errorf("expected %q, got %q", want, phrase.SSML)
+	}
+
+	// The spoken names are escaped
+	if speech := Plain("Gare & Flon").Speak(); speech != "<speak>Gare &amp; Flon</speak>" {
+		t.Errorf("expected the text to be escaped, got %q", speech)
+	}
+}
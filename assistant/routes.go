@@ -0,0 +1,176 @@
+package assistant
+
+import (
+	"log"
+
+	"github.com/gophersch/tlgo"
+)
+
+// routeMatch tells how a route relates to the stops of a departure query
+type routeMatch int
+
+const (
+	routeNotFound routeMatch = iota
+	routeFound
+	// routeUpstream is a route passing by the direction stop before the origin
+	routeUpstream
+)
+
+// routeTowards returns the route passing by the origin and then by the
+// direction stop, which is either its terminus or any downstream stop.
+// The stops of the wayback routes are listed in their travel order too, so
+// both directions are handled alike. Terminus and main routes are preferred.
+func (a *Assistant) routeTowards(routes []tlgo.Route, origin tlgo.Stop, direction tlgo.Stop) (tlgo.Route, routeMatch) {
+
+	match := routeNotFound
+	var best tlgo.Route
+	bestRank := -1
+
+	for _, route := range routes {
+
+		details, err := a.store.GetRoutesDetailsForRouteID(route.ID)
+		if err != nil {
+			// Without its stops, only the terminus tells where the route goes
+			if route.CityDestinationStopName == direction.Name && bestRank < 0 {
+				best, match = route, routeFound
+			}
+			continue
+		}
+
+		originIndex, directionIndex := -1, -1
+		for i, stop := range details.Stops {
+			if stop.StopAreaName == origin.Name && originIndex < 0 {
+				originIndex = i
+			}
+			if stop.StopAreaName == direction.Name {
+				directionIndex = i
+			}
+		}
+
+		if originIndex < 0 || directionIndex < 0 || originIndex == directionIndex {
+			continue
+		}
+
+		if originIndex > directionIndex {
+			if match == routeNotFound {
+				match = routeUpstream
+			}
+			continue
+		}
+
+		rank := 0
+		if route.CityDestinationStopName == direction.Name {
+			rank += 2
+		}
+		if route.MainRoute {
+			rank++
+		}
+
+		if rank > bestRank {
+			best, bestRank, match = route, rank, routeFound
+		}
+	}
+
+	return best, match
+}
+
+// routesTowards returns the routes of all the lines leaving the origin
+// towards the direction stop.
+func (a *Assistant) routesTowards(origin tlgo.Stop, direction tlgo.Stop) ([]lineRoute, routeMatch) {
+
+	match := routeNotFound
+	candidates := []lineRoute{}
+
+	for _, shortName := range origin.LinesShortName {
+
+		line, err := a.store.GetLineByName(shortName)
+		if err != nil {
+			log.Printf("The line %s of stop %s is unknown\n", shortName, origin.Name)
+			continue
+		}
+
+		routes, err := a.store.GetRoutesForLineID(line.ID)
+		if err != nil {
+			continue
+		}
+
+		route, lineMatch := a.routeTowards(routes, origin, direction)
+		switch lineMatch {
+		case routeFound:
+			candidates = append(candidates, lineRoute{line: line, route: route})
+			match = routeFound
+		case routeUpstream:
+			if match == routeNotFound {
+				match = routeUpstream
+			}
+		}
+	}
+	return candidates, match
+}
+
+// boardRoutes returns a route for each line and direction leaving the
+// stop, restricted to the provided line if any. Departures are fetched by
+// direction so a single route, preferably the main one, is kept for each.
+func (a *Assistant) boardRoutes(stop tlgo.Stop, only *tlgo.Line) []lineRoute {
+
+	type direction struct {
+		lineID  string
+		wayback bool
+	}
+
+	byDirection := map[direction]int{}
+	routes := []lineRoute{}
+
+	for _, shortName := range stop.LinesShortName {
+
+		if only != nil && only.ShortName != shortName {
+			continue
+		}
+
+		line, err := a.store.GetLineByName(shortName)
+		if err != nil {
+			log.Printf("The line %s of stop %s is unknown\n", shortName, stop.Name)
+			continue
+		}
+
+		lineRoutes, err := a.store.GetRoutesForLineID(line.ID)
+		if err != nil {
+			continue
+		}
+
+		for _, route := range lineRoutes {
+
+			if !a.leavesStop(route, stop) {
+				continue
+			}
+
+			key := direction{lineID: line.ID, wayback: route.Wayback}
+			if index, hasDirection := byDirection[key]; hasDirection {
+				if route.MainRoute && !routes[index].route.MainRoute {
+					routes[index].route = route
+				}
+				continue
+			}
+
+			byDirection[key] = len(routes)
+			routes = append(routes, lineRoute{line: line, route: route})
+		}
+	}
+	return routes
+}
+
+// leavesStop tells if the route stops at the stop before its terminus
+func (a *Assistant) leavesStop(route tlgo.Route, stop tlgo.Stop) bool {
+
+	details, err := a.store.GetRoutesDetailsForRouteID(route.ID)
+	if err != nil {
+		return false
+	}
+
+	for i, routeStop := range details.Stops {
+		if routeStop.StopAreaName == stop.Name {
+			return i < len(details.Stops)-1
+		}
+	}
+	return false
+}
@@ -0,0 +1,118 @@
+package assistant
+
+import (
+	"testing"
+	"time"
+
+	"github.com/gophersch/tlgo"
+)
+
+func TestRouteTowards(t *testing.T) {
+
+	a := newTestAssistant(t, &stallingProvider{}, false, time.Now())
+
+	routes, err := a.store.GetRoutesForLineID("L2")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name      string
+		origin    string
+		direction string
+		routes    int
+		route     string
+		match     routeMatch
+	}{
+		// Flon is not the terminus of the route going to Gare
+		{"downstream stop", "Ouchy", "Flon", 2, "r2", routeFound},
+		{"terminus", "Ouchy", "Gare", 2, "r2", routeFound},
+		{"wayback", "Flon", "Ouchy", 2, "r2b", routeFound},
+		// Without its wayback route, line 2 only goes towards Gare
+		{"upstream stop", "Flon", "Ouchy", 1, "", routeUpstream},
+	}
+
+	for _, test := range tests {
+		origin, _ := a.store.GetStopByName(test.origin)
+		direction, _ := a.store.GetStopByName(test.direction)
+
+		route, match := a.routeTowards(routes[:test.routes], origin, direction)
+		if match != test.match || route.ID != test.route {
+			t.Errorf("%s: expected route %q (%d), got %q (%d)", test.name, test.route, test.match, route.ID, match)
+		}
+	}
+}
+
+func TestRoutesTowards(t *testing.T) {
+
+	a := newTestAssistant(t, &stallingProvider{}, false, time.Now())
+
+	tests := []struct {
+		origin    string
+		direction string
+		lines     []string
+		match     routeMatch
+	}{
+		{"Flon", "Gare", []string{"2", "3"}, routeFound},
+		{"Flon", "Ouchy", []string{"2"}, routeFound},
+		// Line 3 only goes from Flon to Gare
+		{"Gare", "Flon", []string{"2"}, routeFound},
+		{"Ouchy", "Ouchy", []string{}, routeNotFound},
+	}
+
+	for _, test := range tests {
+		origin, _ := a.store.GetStopByName(test.origin)
+		direction, _ := a.store.GetStopByName(test.direction)
+
+		candidates, match := a.routesTowards(origin, direction)
+		if match != test.match || len(candidates) != len(test.lines) {
+			t.Errorf("%s to %s: expected lines %v (%d), got %d lines (%d)", test.origin, test.direction, test.lines, test.match, len(candidates), match)
+			continue
+		}
+		for i, candidate := range candidates {
+			if candidate.line.ShortName != test.lines[i] {
+				t.Errorf("%s to %s: expected line %s, got %s", test.origin, test.direction, test.lines[i], candidate.line.ShortName)
+			}
+		}
+	}
+}
+
+func TestBoardRoutes(t *testing.T) {
+
+	a := newTestAssistant(t, &stallingProvider{}, false, time.Now())
+
+	line, err := a.store.GetLineByName("2")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name string
+		stop string
+		line *tlgo.Line
+		want []string
+	}{
+		{"all the lines", "Flon", nil, []string{"2 Gare", "2 Ouchy", "3 Gare"}},
+		{"single line", "Flon", &line, []string{"2 Gare", "2 Ouchy"}},
+		// The routes to Gare end there
+		{"terminus", "Gare", nil, []string{"2 Ouchy"}},
+	}
+
+	for _, test := range tests {
+		stop, err := a.store.GetStopByName(test.stop)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		routes := a.boardRoutes(stop, test.line)
+		if len(routes) != len(test.want) {
+			t.Errorf("%s: expected %d routes, got %d", test.name, len(test.want), len(routes))
+			continue
+		}
+		for i, route := range routes {
+			if got := route.line.ShortName + " " + route.destination(); got != test.want[i] {
+				t.Errorf("%s: expected %s, got %s", test.name, test.want[i], got)
+			}
+		}
+	}
+}
@@ -0,0 +1,97 @@
+package assistant
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/yageek/tl-ai/i18n"
+)
+
+// departuresPause separates the departures of a list when they are spoken
+const departuresPause = `<break time="300ms"/>`
+
+// ssmlEscaper escapes the text content of the SSML elements
+var ssmlEscaper = strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;")
+
+// Utterance is a phrase rendered both as plain text, for the screens and
+// the logs, and as SSML, for the speakers.
+type Utterance struct {
+	Text string
+	// SSML is the content of the speak element
+	SSML string
+}
+
+// Plain returns the utterance of a text without markup
+func Plain(text string) Utterance {
+	return Utterance{Text: text, SSML: ssmlEscaper.Replace(text)}
+}
+
+// Speak returns the SSML document of the utterance
+func (u Utterance) Speak() string {
+	return "<speak>" + u.SSML + "</speak>"
+}
+
+// say formats a message of the catalogue. The utterances among the
+// arguments are inserted in their text or SSML form, the other strings
+// are escaped.
+func say(p i18n.Printer, id i18n.MessageID, args ...interface{}) Utterance {
+	texts, ssmls := utteranceArgs(args)
+	return Utterance{Text: p.Sprintf(id, texts...), SSML: p.Sprintf(id, ssmls...)}
+}
+
+// sayPlural formats the form of a message matching the count n
+func sayPlural(p i18n.Printer, id i18n.MessageID, n int, args ...interface{}) Utterance {
+	texts, ssmls := utteranceArgs(args)
+	return Utterance{Text: p.Plural(id, n, texts...), SSML: p.Plural(id, n, ssmls...)}
+}
+
+func utteranceArgs(args []interface{}) ([]interface{}, []interface{}) {
+	texts := make([]interface{}, len(args))
+	ssmls := make([]interface{}, len(args))
+	for i, arg := range args {
+		switch value := arg.(type) {
+		case Utterance:
+			texts[i], ssmls[i] = value.Text, value.SSML
+		case string:
+			texts[i], ssmls[i] = value, ssmlEscaper.Replace(value)
+		default:
+			texts[i], ssmls[i] = value, value
+		}
+	}
+	return texts, ssmls
+}
+
+// joinUtterances joins the utterances with a separator, spoken with a
+// pause when pause is set.
+func joinUtterances(items []Utterance, separator string, pause bool) Utterance {
+	texts := make([]string, len(items))
+	ssmls := make([]string, len(items))
+	for i, item := range items {
+		texts[i], ssmls[i] = item.Text, item.SSML
+	}
+
+	ssmlSeparator := ssmlEscaper.Replace(separator)
+	if pause {
+		ssmlSeparator = strings.TrimRight(ssmlSeparator, " ") + departuresPause + " "
+	}
+	return Utterance{Text: strings.Join(texts, separator), SSML: strings.Join(ssmls, ssmlSeparator)}
+}
+
+// listUtterance joins the items as "a, b et c" in the language of the printer
+func listUtterance(p i18n.Printer, conjunction i18n.MessageID, items []Utterance) Utterance {
+	if len(items) < 2 {
+		return joinUtterances(items, "", false)
+	}
+	return say(p, conjunction, joinUtterances(items[:len(items)-1], ", ", false), items[len(items)-1])
+}
+
+// clockUtterance tells a time, e.g. "18h07" in French. The speakers are
+// told to read it as a time rather than as numbers.
+func clockUtterance(p i18n.Printer, t time.Time) Utterance {
+	t = t.In(TimeZone)
+	return Utterance{
+		Text: p.Sprintf(i18n.Clock, t.Hour(), t.Minute()),
+		SSML: fmt.Sprintf(`<say-as interpret-as="time" format="hms24">%d:%02d</say-as>`, t.Hour(), t.Minute()),
+	}
+}
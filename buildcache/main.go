@@ -7,6 +7,7 @@ import (
 	"flag"
 	"html/template"
 	"os"
+	"time"
 
 	"github.com/yageek/tl-ai/dataprovider"
 )
@@ -14,12 +15,16 @@ import (
 var (
 	output      string
 	packageName string
+	timetable   string
 	tmpl        *template.Template
 )
 
 func init() {
 	flag.StringVar(&output, "output", "", "output path")
 	flag.StringVar(&packageName, "package", "", "package")
+	flag.StringVar(&timetable, "timetable", "", "first service day (YYYY-MM-DD) of the week of timetables to embed. "+
+		"The departures of every stop of every route are paged from the live TL API for a weekday, a Saturday and a Sunday, "+
+		"one request per page of departures at each stop of each route. Holidays and exceptional services are not covered.")
 
 	tm := `package {{.PackageName}}
 
@@ -42,6 +47,18 @@ func main() {
 		panic(err)
 	}
 
+	if timetable != "" {
+		day, err := time.ParseInLocation("2006-01-02", timetable, time.Local)
+		if err != nil {
+			panic(err)
+		}
+
+		data.TripsByRouteID, err = dataprovider.GetAPIWeekTimetable(data, day)
+		if err != nil {
+			panic(err)
+		}
+	}
+
 	file, err := os.Create(output)
 	if err != nil {
 		panic(err)
@@ -9,6 +9,7 @@ type APIRawData struct {
 	Lines                  []tlgo.Line
 	RoutesByLineID         map[string][]tlgo.Route
 	RoutesDetailsByRouteID map[string]tlgo.RouteDetails
+	TripsByRouteID         map[string][]Trip
 }
 
 func GetAPIData() (APIRawData, error) {
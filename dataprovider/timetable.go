@@ -0,0 +1,224 @@
+package dataprovider
+
+import (
+	"time"
+
+	"github.com/gophersch/tlgo"
+)
+
+// StopTime is the scheduled passage of a trip at a stop area
+type StopTime struct {
+	StopAreaName string
+	// Time is the offset since the start of the service day
+	Time time.Duration
+}
+
+// ServiceDay is the kind of days a trip runs on, the timetables of the
+// network differing on weekdays, Saturdays and Sundays
+type ServiceDay int
+
+const (
+	// EveryDay is the trips sampled without their kind of day
+	EveryDay ServiceDay = iota
+	Weekdays
+	Saturdays
+	// Sundays are also the timetable of the public holidays, which are
+	// not told apart from the other days
+	Sundays
+)
+
+// ServiceDayOf returns the kind of the day of the time
+func ServiceDayOf(t time.Time) ServiceDay {
+	switch t.Weekday() {
+	case time.Saturday:
+		return Saturdays
+	case time.Sunday:
+		return Sundays
+	}
+	return Weekdays
+}
+
+// On returns the time of the passage on the day. The offset is counted on
+// the clock, so the passages keep their time on the days the clock changes.
+func (s StopTime) On(day time.Time) time.Time {
+	return time.Date(day.Year(), day.Month(), day.Day(), 0, 0, 0, int(s.Time), day.Location())
+}
+
+// Includes tells if the day of the time is of this kind
+func (d ServiceDay) Includes(t time.Time) bool {
+	return d == EveryDay || d == ServiceDayOf(t)
+}
+
+// Trip is a single run of a vehicle along a route
+type Trip struct {
+	StopTimes []StopTime
+	// Day is the kind of days the trip runs on
+	Day ServiceDay
+}
+
+// RunsOn tells if the trip runs on the day of the time
+func (t Trip) RunsOn(day time.Time) bool {
+	return t.Day.Includes(day)
+}
+
+// GetAPIWeekTimetable samples a day of each kind from the provided one
+// on, which gives the timetable of the whole week.
+//
+// It pages the live TL API three times as much as GetAPITimetable. The
+// week is the usual one: the holidays and the exceptional services of the
+// sampled days are taken as the timetable of all the days of their kind.
+func GetAPIWeekTimetable(data APIRawData, from time.Time) (map[string][]Trip, error) {
+
+	tripsByRouteID := make(map[string][]Trip)
+	sampled := map[ServiceDay]bool{}
+
+	for day := from; len(sampled) < 3; day = day.AddDate(0, 0, 1) {
+
+		if sampled[ServiceDayOf(day)] {
+			continue
+		}
+		sampled[ServiceDayOf(day)] = true
+
+		dayTrips, err := GetAPITimetable(data, day)
+		if err != nil {
+			return nil, err
+		}
+		for routeID, trips := range dayTrips {
+			tripsByRouteID[routeID] = append(tripsByRouteID[routeID], trips...)
+		}
+	}
+
+	return tripsByRouteID, nil
+}
+
+// GetAPITimetable samples the departures of every route during the
+// service day and rebuilds the trips running along them, which run on the
+// days of the same kind.
+//
+// There is no static timetable, so the departures are paged from the live
+// TL API: one request per page of departures at each stop of each route,
+// which makes the sampling of the network last long. The trips are rebuilt from
+// the passages at the stops and the trips running past midnight end with
+// the service day.
+func GetAPITimetable(data APIRawData, day time.Time) (map[string][]Trip, error) {
+
+	client := tlgo.NewClient()
+
+	dayStart := time.Date(day.Year(), day.Month(), day.Day(), 0, 0, 0, 0, day.Location())
+	dayEnd := dayStart.Add(24 * time.Hour)
+
+	stopsByName := make(map[string]tlgo.Stop, len(data.Stops))
+	for _, stop := range data.Stops {
+		stopsByName[stop.Name] = stop
+	}
+
+	tripsByRouteID := make(map[string][]Trip)
+
+	for lineID, routes := range data.RoutesByLineID {
+		for _, route := range routes {
+
+			details, hasDetails := data.RoutesDetailsByRouteID[route.ID]
+			if !hasDetails {
+				continue
+			}
+
+			var trips []Trip
+			previousName := ""
+			for _, stopDetails := range details.Stops {
+
+				stop, hasStop := stopsByName[stopDetails.StopAreaName]
+				if !hasStop {
+					continue
+				}
+
+				times, err := sampleDepartures(client, stop.ID, lineID, route.Wayback, dayStart, dayEnd)
+				if err != nil {
+					return nil, err
+				}
+
+				if trips == nil {
+					trips = make([]Trip, len(times))
+					for i, t := range times {
+						trips[i].StopTimes = []StopTime{{StopAreaName: stop.Name, Time: t}}
+						trips[i].Day = ServiceDayOf(dayStart)
+					}
+				} else {
+					chainStopTimes(trips, previousName, stop.Name, times)
+				}
+				previousName = stop.Name
+			}
+
+			if len(trips) > 0 {
+				tripsByRouteID[route.ID] = trips
+			}
+		}
+	}
+
+	return tripsByRouteID, nil
+}
+
+// sampleDepartures lists all the departure offsets of a line at a stop
+// between the two provided times.
+func sampleDepartures(client *tlgo.Client, stopID, lineID string, wayback bool, from, to time.Time) ([]time.Duration, error) {
+
+	times := []time.Duration{}
+
+	for from.Before(to) {
+		journeys, err := client.ListStopDepartures(stopID, lineID, from, wayback)
+		if err != nil {
+			return nil, err
+		}
+
+		if len(journeys) == 0 {
+			break
+		}
+
+		last := from
+		for _, journey := range journeys {
+			departure := from.Add(journey.WaitingTime)
+			if departure.Before(to) && (len(times) == 0 || ClockOffset(departure) > times[len(times)-1]) {
+				times = append(times, ClockOffset(departure))
+			}
+			if departure.After(last) {
+				last = departure
+			}
+		}
+		from = last.Add(time.Minute)
+	}
+
+	return times, nil
+}
+
+// ClockOffset returns the time of the clock as an offset since the start
+// of the day, as StopTime.On reads it
+func ClockOffset(t time.Time) time.Duration {
+	hour, min, sec := t.Clock()
+	return time.Duration(hour)*time.Hour + time.Duration(min)*time.Minute + time.Duration(sec)*time.Second
+}
+
+// chainStopTimes extends each trip that reached the previous stop with
+// the first departure at the stop following its last passage. Vehicles
+// of a route never overtake each other, so departures are given to the
+// trips in order. A trip that can not be continued ends at its last stop.
+func chainStopTimes(trips []Trip, previousName, stopName string, times []time.Duration) {
+
+	cursor := 0
+	for i := range trips {
+		stopTimes := trips[i].StopTimes
+		last := stopTimes[len(stopTimes)-1]
+
+		if last.StopAreaName != previousName {
+			continue
+		}
+
+		for cursor < len(times) && times[cursor] < last.Time {
+			cursor++
+		}
+		if cursor >= len(times) {
+			break
+		}
+
+		trips[i].StopTimes = append(stopTimes, StopTime{StopAreaName: stopName, Time: times[cursor]})
+		cursor++
+	}
+}
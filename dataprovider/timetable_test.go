@@ -0,0 +1,73 @@
+package dataprovider
+
+import (
+	"testing"
+	"time"
+)
+
+func TestTripRunsOn(t *testing.T) {
+
+	zurich, err := time.LoadLocation("Europe/Zurich")
+	if err != nil {
+		t.Skip(err)
+	}
+
+	friday := time.Date(2026, 10, 23, 12, 0, 0, 0, zurich)
+	saturday := friday.AddDate(0, 0, 1)
+	sunday := friday.AddDate(0, 0, 2)
+
+	tests := []struct {
+		day  ServiceDay
+		runs []bool
+	}{
+		{EveryDay, []bool{true, true, true}},
+		{Weekdays, []bool{true, false, false}},
+		{Saturdays, []bool{false, true, false}},
+		{Sundays, []bool{false, false, true}},
+	}
+
+	for _, test := range tests {
+		for i, day := range []time.Time{friday, saturday, sunday} {
+			if got := (Trip{Day: test.day}).RunsOn(day); got != test.runs[i] {
+				t.Errorf("trip of day %d on %s: expected %v, got %v", test.day, day.Weekday(), test.runs[i], got)
+			}
+		}
+	}
+}
+
+func TestStopTimeOnClockChange(t *testing.T) {
+
+	zurich, err := time.LoadLocation("Europe/Zurich")
+	if err != nil {
+		t.Skip(err)
+	}
+
+	// The clock goes back from 3:00 to 2:00 that day
+	day := time.Date(2026, 10, 25, 0, 0, 0, 0, zurich)
+	departure := time.Date(2026, 10, 25, 9, 5, 0, 0, zurich)
+
+	offset := ClockOffset(departure)
+	if offset != 9*time.Hour+5*time.Minute {
+		t.Errorf("expected the offset of 9:05, got %s", offset)
+	}
+	if got := (StopTime{Time: offset}).On(day); !got.Equal(departure) {
+		t.Errorf("expected %s, got %s", departure, got)
+	}
+}
+
+func TestChainStopTimes(t *testing.T) {
+
+	trips := []Trip{
+		{StopTimes: []StopTime{{StopAreaName: "A", Time: 8 * time.Hour}}},
+		{StopTimes: []StopTime{{StopAreaName: "A", Time: 9 * time.Hour}}},
+	}
+
+	chainStopTimes(trips, "A", "B", []time.Duration{7 * time.Hour, 8*time.Hour + 5*time.Minute, 9*time.Hour + 5*time.Minute})
+
+	for i, want := range []time.Duration{8*time.Hour + 5*time.Minute, 9*time.Hour + 5*time.Minute} {
+		stopTimes := trips[i].StopTimes
+		if len(stopTimes) != 2 || stopTimes[1].StopAreaName != "B" || stopTimes[1].Time != want {
+			t.Errorf("trip %d: expected to reach B at %s, got %+v", i, want, stopTimes)
+		}
+	}
+}
@@ -10,11 +10,12 @@ import (
 	"strings"
 
 	"github.com/gophersch/tlgo"
+	"github.com/yageek/tl-ai/i18n"
 	"google.golang.org/api/option"
 
 	"cloud.google.com/go/dialogflow/apiv2"
+	"cloud.google.com/go/dialogflow/apiv2/dialogflowpb"
 	"google.golang.org/api/iterator"
-	dialogflowpb "google.golang.org/genproto/googleapis/cloud/dialogflow/v2"
 )
 
 const (
@@ -111,6 +112,10 @@ func main() {
 		log.Fatalf("Impossible to create the line entity: %v", err)
 	}
 
+	if err := translateEntity(ctx, c, lineEntity, lineSynonyms); err != nil {
+		log.Fatalf("Impossible to translate the line entity: %v", err)
+	}
+
 	// Populate stop
 	if stopEntity, hasStop := entities[StopEntityName]; hasStop {
 		log.Printf("Previous stop entity defined, deleting them...")
@@ -139,16 +144,54 @@ func main() {
 		log.Fatalf("Impossible to create the stop entity: %v", err)
 	}
 
+	if err := translateEntity(ctx, c, stopEntity, stopSynonyms); err != nil {
+		log.Fatalf("Impossible to translate the stop entity: %v", err)
+	}
+
 }
 
 func createEmptyEntity(ctx context.Context, c *dialogflow.EntityTypesClient, entity *dialogflowpb.EntityType) (*dialogflowpb.EntityType, error) {
 	req := &dialogflowpb.CreateEntityTypeRequest{
-		Parent:     fmt.Sprintf("projects/%s/agent", PROJECT_ID),
-		EntityType: entity,
+		Parent:       fmt.Sprintf("projects/%s/agent", PROJECT_ID),
+		EntityType:   entity,
+		LanguageCode: string(i18n.DefaultLanguage),
 	}
 
 	return c.CreateEntityType(ctx, req)
 }
+
+// translateEntity sets the synonyms of the entity values in the other
+// languages of the agent.
+func translateEntity(ctx context.Context, c *dialogflow.EntityTypesClient, entity *dialogflowpb.EntityType, synonyms func(string, i18n.Language) []string) error {
+
+	for _, language := range i18n.Languages {
+		if language == i18n.DefaultLanguage {
+			continue
+		}
+
+		entities := make([]*dialogflowpb.EntityType_Entity, len(entity.Entities))
+		for i, value := range entity.Entities {
+			entities[i] = &dialogflowpb.EntityType_Entity{
+				Value:    value.Value,
+				Synonyms: synonyms(value.Value, language),
+			}
+		}
+
+		log.Printf("Translating the %s entity in %s...", entity.DisplayName, language)
+		op, err := c.BatchUpdateEntities(ctx, &dialogflowpb.BatchUpdateEntitiesRequest{
+			Parent:       entity.Name,
+			Entities:     entities,
+			LanguageCode: string(language),
+		})
+		if err != nil {
+			return err
+		}
+		if err := op.Wait(ctx); err != nil {
+			return err
+		}
+	}
+	return nil
+}
 func deleteEntity(ctx context.Context, c *dialogflow.EntityTypesClient, entity *dialogflowpb.EntityType) error {
 	req := &dialogflowpb.DeleteEntityTypeRequest{
 		Name: entity.Name,
@@ -160,7 +203,7 @@ func existingEntitiesName(ctx context.Context, c *dialogflow.EntityTypesClient)
 	entities := make(map[string]*dialogflowpb.EntityType, 1)
 
 	req := &dialogflowpb.ListEntityTypesRequest{
-		LanguageCode: "fr",
+		LanguageCode: string(i18n.DefaultLanguage),
 		Parent:       fmt.Sprintf("projects/%s/agent", PROJECT_ID),
 	}
 	it := c.ListEntityTypes(ctx, req)
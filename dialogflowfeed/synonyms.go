@@ -0,0 +1,92 @@
+package main
+
+import (
+	"strings"
+
+	"github.com/yageek/tl-ai/i18n"
+)
+
+// linePrefixes are the words used before the line numbers, e.g. "ligne 9"
+var linePrefixes = map[i18n.Language]string{
+	i18n.French:  "ligne",
+	i18n.German:  "Linie",
+	i18n.Italian: "linea",
+	i18n.English: "line",
+}
+
+// stopWords translates the common words of the stop names. The stops keep
+// their French names as values, only the synonyms are translated.
+var stopWords = map[i18n.Language]map[string]string{
+	i18n.German: {
+		"Gare":       "Bahnhof",
+		"Place":      "Platz",
+		"Pont":       "Brücke",
+		"Église":     "Kirche",
+		"Hôpital":    "Spital",
+		"Lac":        "See",
+		"Port":       "Hafen",
+		"Parc":       "Park",
+		"Château":    "Schloss",
+		"Cathédrale": "Kathedrale",
+		"Université": "Universität",
+		"Centre":     "Zentrum",
+		"Piscine":    "Schwimmbad",
+	},
+	i18n.Italian: {
+		"Gare":       "Stazione",
+		"Place":      "Piazza",
+		"Pont":       "Ponte",
+		"Église":     "Chiesa",
+		"Hôpital":    "Ospedale",
+		"Lac":        "Lago",
+		"Port":       "Porto",
+		"Parc":       "Parco",
+		"Château":    "Castello",
+		"Cathédrale": "Cattedrale",
+		"Université": "Università",
+		"Centre":     "Centro",
+		"Piscine":    "Piscina",
+	},
+	i18n.English: {
+		"Gare":       "Station",
+		"Place":      "Square",
+		"Pont":       "Bridge",
+		"Église":     "Church",
+		"Hôpital":    "Hospital",
+		"Lac":        "Lake",
+		"Port":       "Harbour",
+		"Parc":       "Park",
+		"Château":    "Castle",
+		"Cathédrale": "Cathedral",
+		"Université": "University",
+		"Centre":     "Centre",
+		"Piscine":    "Swimming pool",
+	},
+}
+
+// lineSynonyms returns the ways to name a line in the language
+func lineSynonyms(shortName string, language i18n.Language) []string {
+	name := filter(shortName)
+	return []string{name, linePrefixes[language] + " " + name}
+}
+
+// stopSynonyms returns the ways to name a stop in the language: its
+// French name, understood by everyone, and its translated words if any.
+func stopSynonyms(name string, language i18n.Language) []string {
+
+	name = filter(name)
+	words := strings.Fields(name)
+
+	isTranslated := false
+	for i, word := range words {
+		if translation, hasTranslation := stopWords[language][word]; hasTranslation {
+			words[i] = translation
+			isTranslated = true
+		}
+	}
+
+	if !isTranslated {
+		return []string{name}
+	}
+	return []string{name, strings.Join(words, " ")}
+}
@@ -0,0 +1,65 @@
+package favourites
+
+import (
+	"encoding/json"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+// favouritesBucket holds the JSON favourites keyed by user ID
+var favouritesBucket = []byte("favourites")
+
+// BoltStore persists the favourites in a BoltDB file
+type BoltStore struct {
+	db *bolt.DB
+}
+
+// OpenBoltStore opens the BoltDB file, creating it when missing
+func OpenBoltStore(path string) (*BoltStore, error) {
+
+	db, err := bolt.Open(path, 0600, &bolt.Options{Timeout: time.Second})
+	if err != nil {
+		return nil, err
+	}
+
+	err = db.Update(func(tx *bolt.Tx) error {
+		_, err := tx.CreateBucketIfNotExists(favouritesBucket)
+		return err
+	})
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+
+	return &BoltStore{db: db}, nil
+}
+
+func (s *BoltStore) Get(userID string) (Favourite, error) {
+
+	favourite := Favourite{}
+	err := s.db.View(func(tx *bolt.Tx) error {
+		value := tx.Bucket(favouritesBucket).Get([]byte(userID))
+		if value == nil {
+			return ErrNotFound
+		}
+		return json.Unmarshal(value, &favourite)
+	})
+	return favourite, err
+}
+
+func (s *BoltStore) Put(userID string, favourite Favourite) error {
+
+	value, err := json.Marshal(favourite)
+	if err != nil {
+		return err
+	}
+
+	return s.db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket(favouritesBucket).Put([]byte(userID), value)
+	})
+}
+
+func (s *BoltStore) Close() error {
+	return s.db.Close()
+}
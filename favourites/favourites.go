@@ -0,0 +1,63 @@
+// Package favourites remembers the usual departure query of the users so
+// they can ask for it without telling the line and the stops again.
+package favourites
+
+import (
+	"errors"
+	"sync"
+)
+
+var (
+	ErrNotFound = errors.New("Favourite not found")
+)
+
+// Favourite is the usual departure of a user. The names are the resolved
+// ones so the query can be replayed without any ambiguity.
+type Favourite struct {
+	Line      string `json:"line"`
+	Origin    string `json:"origin"`
+	Direction string `json:"direction"`
+}
+
+// Store persists the favourites by user ID
+type Store interface {
+	// Get returns the favourite of the user or ErrNotFound
+	Get(userID string) (Favourite, error)
+	// Put saves the favourite of the user, replacing the previous one
+	Put(userID string, favourite Favourite) error
+	Close() error
+}
+
+// MemoryStore keeps the favourites in memory, they are lost on restart
+type MemoryStore struct {
+	mu         sync.RWMutex
+	favourites map[string]Favourite
+}
+
+// NewMemoryStore returns an empty memory store
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{favourites: map[string]Favourite{}}
+}
+
+func (s *MemoryStore) Get(userID string) (Favourite, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	favourite, hasFavourite := s.favourites[userID]
+	if !hasFavourite {
+		return Favourite{}, ErrNotFound
+	}
+	return favourite, nil
+}
+
+func (s *MemoryStore) Put(userID string, favourite Favourite) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.favourites[userID] = favourite
+	return nil
+}
+
+func (s *MemoryStore) Close() error {
+	return nil
+}
@@ -0,0 +1,79 @@
+package favourites
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestStores(t *testing.T) {
+
+	bolt, err := OpenBoltStore(filepath.Join(t.TempDir(), "favourites.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer bolt.Close()
+
+	stores := map[string]Store{
+		"memory": NewMemoryStore(),
+		"bolt":   bolt,
+	}
+
+	for name, store := range stores {
+		if _, err := store.Get("alice"); err != ErrNotFound {
+			t.Errorf("%s: expected %v, got %v", name, ErrNotFound, err)
+		}
+
+		for _, favourite := range []Favourite{
+			{Line: "2", Origin: "Flon", Direction: "Ouchy"},
+			// The favourite replaces the previous one
+			{Line: "9", Origin: "Bessières", Direction: "Prilly-Église"},
+		} {
+			if err := store.Put("alice", favourite); err != nil {
+				t.Fatalf("%s: %v", name, err)
+			}
+
+			got, err := store.Get("alice")
+			if err != nil {
+				t.Fatalf("%s: %v", name, err)
+			}
+			if got != favourite {
+				t.Errorf("%s: expected %+v, got %+v", name, favourite, got)
+			}
+		}
+
+		if _, err := store.Get("bob"); err != ErrNotFound {
+			t.Errorf("%s: expected the favourites to be kept by user, got %v", name, err)
+		}
+	}
+}
+
+func TestBoltStoreIsPersisted(t *testing.T) {
+
+	path := filepath.Join(t.TempDir(), "favourites.db")
+	favourite := Favourite{Line: "2", Origin: "Flon", Direction: "Ouchy"}
+
+	store, err := OpenBoltStore(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := store.Put("alice", favourite); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	store, err = OpenBoltStore(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer store.Close()
+
+	got, err := store.Get("alice")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != favourite {
+		t.Errorf("expected %+v, got %+v", favourite, got)
+	}
+}
@@ -0,0 +1,139 @@
+package geo
+
+import (
+	"math"
+	"sort"
+)
+
+const earthRadius = 6371000.0
+
+// Point is a geographic position in degrees
+type Point struct {
+	Lat float64
+	Lng float64
+}
+
+// IsZero tells if the point has no coordinates
+func (p Point) IsZero() bool {
+	return p.Lat == 0 && p.Lng == 0
+}
+
+// Distance returns the great circle distance in meters between two points
+func Distance(a, b Point) float64 {
+	lat1, lat2 := a.Lat*math.Pi/180, b.Lat*math.Pi/180
+	dLat := lat2 - lat1
+	dLng := (b.Lng - a.Lng) * math.Pi / 180
+
+	h := math.Sin(dLat/2)*math.Sin(dLat/2) + math.Cos(lat1)*math.Cos(lat2)*math.Sin(dLng/2)*math.Sin(dLng/2)
+	return 2 * earthRadius * math.Asin(math.Sqrt(h))
+}
+
+// Neighbour is a point of a grid found near a position
+type Neighbour struct {
+	// Index is the position of the point in the slice given to NewGrid
+	Index    int
+	Distance float64
+}
+
+type cell struct {
+	x int
+	y int
+}
+
+// Grid is a spatial index bucketing points into square cells
+type Grid struct {
+	points   []Point
+	cellSize float64
+	cosLat   float64
+	cells    map[cell][]int
+}
+
+// NewGrid indexes the points into cells of cellSize meters. Points
+// without coordinates are not indexed.
+func NewGrid(points []Point, cellSize float64) *Grid {
+
+	g := &Grid{
+		points:   points,
+		cellSize: cellSize,
+		cells:    map[cell][]int{},
+	}
+
+	// Project the cells around the mean latitude of the points
+	count, lat := 0, 0.0
+	for _, p := range points {
+		if !p.IsZero() {
+			lat += p.Lat
+			count++
+		}
+	}
+	if count > 0 {
+		lat /= float64(count)
+	}
+	g.cosLat = math.Cos(lat * math.Pi / 180)
+
+	for i, p := range points {
+		if p.IsZero() {
+			continue
+		}
+		c := g.cellOf(p)
+		g.cells[c] = append(g.cells[c], i)
+	}
+	return g
+}
+
+func (g *Grid) cellOf(p Point) cell {
+	metersPerDegree := earthRadius * math.Pi / 180
+	return cell{
+		x: int(math.Floor(p.Lng * metersPerDegree * g.cosLat / g.cellSize)),
+		y: int(math.Floor(p.Lat * metersPerDegree / g.cellSize)),
+	}
+}
+
+// Within returns the points at most radius meters away from p, closest first
+func (g *Grid) Within(p Point, radius float64) []Neighbour {
+
+	neighbours := []Neighbour{}
+	center := g.cellOf(p)
+	reach := int(math.Ceil(radius / g.cellSize))
+
+	for x := center.x - reach; x <= center.x+reach; x++ {
+		for y := center.y - reach; y <= center.y+reach; y++ {
+			for _, i := range g.cells[cell{x, y}] {
+				if d := Distance(p, g.points[i]); d <= radius {
+					neighbours = append(neighbours, Neighbour{Index: i, Distance: d})
+				}
+			}
+		}
+	}
+
+	sort.Slice(neighbours, func(i, j int) bool {
+		return neighbours[i].Distance < neighbours[j].Distance
+	})
+	return neighbours
+}
+
+// Nearest returns the count points closest to p and at most maxRadius
+// meters away from it
+func (g *Grid) Nearest(p Point, count int, maxRadius float64) []Neighbour {
+
+	if count <= 0 || len(g.cells) == 0 || maxRadius <= 0 {
+		return []Neighbour{}
+	}
+
+	// Widen the search ring by ring until enough points are found
+	// closer than the area already covered. The cells scanned growing
+	// with the square of the radius, it is bounded.
+	neighbours := []Neighbour{}
+	for radius := math.Min(g.cellSize, maxRadius); ; radius *= 2 {
+		radius = math.Min(radius, maxRadius)
+		neighbours = g.Within(p, radius)
+		if len(neighbours) >= count || radius >= maxRadius {
+			break
+		}
+	}
+
+	if len(neighbours) > count {
+		neighbours = neighbours[:count]
+	}
+	return neighbours
+}
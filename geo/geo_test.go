@@ -0,0 +1,39 @@
+package geo
+
+import "testing"
+
+// Stops around Lausanne
+var testPoints = []Point{
+	{Lat: 46.5197, Lng: 6.6323}, // Saint-François
+	{Lat: 46.5220, Lng: 6.6260}, // Chauderon
+	{Lat: 46.5168, Lng: 6.6291}, // Gare
+	{Lat: 46.4900, Lng: 6.7000}, // Lutry
+}
+
+func TestNearest(t *testing.T) {
+
+	grid := NewGrid(testPoints, 500)
+
+	neighbours := grid.Nearest(Point{Lat: 46.5219, Lng: 6.6262}, 2, 5000)
+	if len(neighbours) != 2 {
+		t.Fatalf("expected 2 neighbours, got %d", len(neighbours))
+	}
+	if neighbours[0].Index != 1 || neighbours[1].Index != 0 {
+		t.Errorf("expected Chauderon then Saint-François, got %d then %d", neighbours[0].Index, neighbours[1].Index)
+	}
+
+	// Lutry is more than 5 km away from Chauderon
+	if neighbours := grid.Nearest(Point{Lat: 46.5219, Lng: 6.6262}, 10, 5000); len(neighbours) != 3 {
+		t.Errorf("expected the 3 points within 5 km, got %d", len(neighbours))
+	}
+}
+
+func TestNearestFarAway(t *testing.T) {
+
+	grid := NewGrid(testPoints, 500)
+
+	// A user in New York must not scan the cells of the whole earth
+	if neighbours := grid.Nearest(Point{Lat: 40.7128, Lng: -74.0060}, 1, 5000); len(neighbours) != 0 {
+		t.Errorf("expected no neighbour, got %d", len(neighbours))
+	}
+}
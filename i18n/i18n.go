@@ -0,0 +1,112 @@
+// Package i18n holds the messages told to the users in the languages of
+// the assistant.
+package i18n
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Language is the ISO 639-1 code of a supported language
+type Language string
+
+const (
+	French  Language = "fr"
+	German  Language = "de"
+	Italian Language = "it"
+	English Language = "en"
+
+	// DefaultLanguage is used for the unsupported languages
+	DefaultLanguage = French
+)
+
+// Languages are the supported languages, the default one first
+var Languages = []Language{French, German, Italian, English}
+
+// ParseLanguage returns the language of a language code or locale such as
+// "de" or "de-CH", the default language when it is not supported.
+func ParseLanguage(code string) Language {
+
+	base := strings.ToLower(code)
+	if i := strings.IndexAny(base, "-_"); i >= 0 {
+		base = base[:i]
+	}
+
+	for _, language := range Languages {
+		if string(language) == base {
+			return language
+		}
+	}
+	return DefaultLanguage
+}
+
+// isOne tells if the singular form is used for the count, following the
+// CLDR plural rules of the language: French uses it for 0 and 1.
+func (l Language) isOne(n int) bool {
+	if l == French {
+		return n == 0 || n == 1
+	}
+	return n == 1
+}
+
+// MessageID identifies a message of the catalogue
+type MessageID string
+
+// Message is a format string of a language. Other is the plural form,
+// used when the message does not depend on a count.
+type Message struct {
+	One   string
+	Other string
+}
+
+// Printer formats the messages of the catalogue in a language
+type Printer struct {
+	language Language
+}
+
+// NewPrinter returns a printer for the language
+func NewPrinter(language Language) Printer {
+	return Printer{language: language}
+}
+
+// Language returns the language of the printer
+func (p Printer) Language() Language {
+	return p.language
+}
+
+// Sprintf formats the message with the arguments
+func (p Printer) Sprintf(id MessageID, args ...interface{}) string {
+	return fmt.Sprintf(p.message(id).Other, args...)
+}
+
+// Plural formats the form of the message matching the count n
+func (p Printer) Plural(id MessageID, n int, args ...interface{}) string {
+	message := p.message(id)
+	if p.language.isOne(n) && message.One != "" {
+		return fmt.Sprintf(message.One, args...)
+	}
+	return fmt.Sprintf(message.Other, args...)
+}
+
+// message returns the message in the language of the printer, in the
+// default language when it is not translated.
+func (p Printer) message(id MessageID) Message {
+	if message, hasMessage := catalogue[p.language][id]; hasMessage {
+		return message
+	}
+	if message, hasMessage := catalogue[DefaultLanguage][id]; hasMessage {
+		return message
+	}
+	return Message{Other: string(id)}
+}
+
+// List joins the items as "a, b ou c" in the language of the printer
+func (p Printer) List(conjunction MessageID, items []string) string {
+	if len(items) == 0 {
+		return ""
+	}
+	if len(items) == 1 {
+		return items[0]
+	}
+	return p.Sprintf(conjunction, strings.Join(items[:len(items)-1], ", "), items[len(items)-1])
+}
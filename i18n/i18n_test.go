@@ -0,0 +1,115 @@
+package i18n
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestParseLanguage(t *testing.T) {
+
+	tests := []struct {
+		code string
+		want Language
+	}{
+		{"fr", French},
+		{"de-CH", German},
+		{"it_CH", Italian},
+		{"EN-us", English},
+		{"es", DefaultLanguage},
+		{"", DefaultLanguage},
+	}
+
+	for _, test := range tests {
+		if language := ParseLanguage(test.code); language != test.want {
+			t.Errorf("%q: expected %s, got %s", test.code, test.want, language)
+		}
+	}
+}
+
+func TestPlural(t *testing.T) {
+
+	tests := []struct {
+		language Language
+		n        int
+		want     string
+	}{
+		// French uses the singular for zero
+		{French, 0, "0 minute"},
+		{French, 1, "1 minute"},
+		{French, 2, "2 minutes"},
+		{English, 0, "0 minutes"},
+		{English, 1, "1 minute"},
+		{German, 2, "2 Minuten"},
+	}
+
+	for _, test := range tests {
+		p := NewPrinter(test.language)
+		if text := p.Plural(Minutes, test.n, fmt.Sprint(test.n)); text != test.want {
+			t.Errorf("%s %d: expected %q, got %q", test.language, test.n, test.want, text)
+		}
+	}
+}
+
+func TestList(t *testing.T) {
+
+	tests := []struct {
+		language Language
+		items    []string
+		want     string
+	}{
+		{French, []string{}, ""},
+		{French, []string{"Flon"}, "Flon"},
+		{French, []string{"Flon", "Gare", "Ouchy"}, "Flon, Gare ou Ouchy"},
+		{German, []string{"Flon", "Gare"}, "Flon oder Gare"},
+	}
+
+	for _, test := range tests {
+		if text := NewPrinter(test.language).List(Or, test.items); text != test.want {
+			t.Errorf("%s %v: expected %q, got %q", test.language, test.items, test.want, text)
+		}
+	}
+}
+
+func TestUnknownMessage(t *testing.T) {
+	if text := NewPrinter(English).Sprintf("unknown"); text != "unknown" {
+		t.Errorf("expected the message ID, got %q", text)
+	}
+}
+
+// anyVerb formats itself as "x" with any verb
+type anyVerb struct{}
+
+func (anyVerb) Format(f fmt.State, verb rune) {
+	f.Write([]byte("x"))
+}
+
+// arity returns the number of arguments formatted by the message
+func arity(format string) int {
+	args := []interface{}{}
+	for strings.Contains(fmt.Sprintf(format, args...), "%!") && len(args) < 10 {
+		args = append(args, anyVerb{})
+	}
+	return len(args)
+}
+
+func TestCatalogueIsComplete(t *testing.T) {
+
+	for id, message := range catalogue[DefaultLanguage] {
+		want := arity(message.Other)
+
+		for _, language := range Languages {
+			translation, isTranslated := catalogue[language][id]
+			if !isTranslated {
+				t.Errorf("%s: %s is not translated", language, id)
+				continue
+			}
+
+			for _, format := range []string{translation.One, translation.Other} {
+				if format != "" && arity(format) != want {
+					t.Errorf("%s: %s formats %d arguments instead of %d", language, id, arity(format), want)
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,417 @@
+package i18n
+
+// Identifiers of the messages
+const (
+	InternalError MessageID = "internal-error"
+	Or            MessageID = "or"
+	And           MessageID = "and"
+	Then          MessageID = "then"
+	ThenSeparator MessageID = "then-separator"
+
+	// Stops and lines
+	AmbiguousStop    MessageID = "ambiguous-stop"
+	StopNotFound     MessageID = "stop-not-found"
+	LineNotFound     MessageID = "line-not-found"
+	UnknownPosition  MessageID = "unknown-position"
+	NoStopNearby     MessageID = "no-stop-nearby"
+	NoLineStopNearby MessageID = "no-line-stop-nearby"
+
+	// Departures
+	LineNotAtStop       MessageID = "line-not-at-stop"
+	LineUnavailable     MessageID = "line-unavailable"
+	LineUpstream        MessageID = "line-upstream"
+	NoRoute             MessageID = "no-route"
+	AfterTime           MessageID = "after-time"
+	NoDeparture         MessageID = "no-departure"
+	NoOtherDeparture    MessageID = "no-other-departure"
+	NextDeparture       MessageID = "next-departure"
+	FollowingDeparture  MessageID = "following-departure"
+	NextDepartures      MessageID = "next-departures"
+	FollowingDepartures MessageID = "following-departures"
+	RouteTitle          MessageID = "route-title"
+	NoLineUpstream      MessageID = "no-line-upstream"
+	NoDirectLine        MessageID = "no-direct-line"
+	NoDepartureTowards  MessageID = "no-departure-towards"
+	AnyLineDeparture    MessageID = "any-line-departure"
+	NoLineAtStop        MessageID = "no-line-at-stop"
+	NoDepartureAtStop   MessageID = "no-departure-at-stop"
+	BoardDeparture      MessageID = "board-departure"
+	Board               MessageID = "board"
+	BoardTitle          MessageID = "board-title"
+
+	// Degraded answers
+	ScheduledDepartures   MessageID = "scheduled-departures"
+	DeparturesUnavailable MessageID = "departures-unavailable"
+
+	// Times
+	Clock        MessageID = "clock"
+	AtClock      MessageID = "at-clock"
+	Now          MessageID = "now"
+	InOneMinute  MessageID = "in-one-minute"
+	In           MessageID = "in"
+	Minutes      MessageID = "minutes"
+	ShortMinutes MessageID = "short-minutes"
+
+	// Journeys
+	WalkLeg           MessageID = "walk-leg"
+	FirstRideLeg      MessageID = "first-ride-leg"
+	TimedFirstRideLeg MessageID = "timed-first-ride-leg"
+	TomorrowRideLeg   MessageID = "tomorrow-ride-leg"
+	TransferLeg       MessageID = "transfer-leg"
+	Journey           MessageID = "journey"
+	AlreadyThere      MessageID = "already-there"
+	NoItinerary       MessageID = "no-itinerary"
+
+	// Conversation
+	UnknownTime           MessageID = "unknown-time"
+	UnknownCount          MessageID = "unknown-count"
+	UnknownBus            MessageID = "unknown-bus"
+	UnknownStop           MessageID = "unknown-stop"
+	NotUnderstoodQuestion MessageID = "not-understood-question"
+	RequestNotUnderstood  MessageID = "request-not-understood"
+	Welcome               MessageID = "welcome"
+	Goodbye               MessageID = "goodbye"
+	FollowUpReprompt      MessageID = "follow-up-reprompt"
+
+	// Screens
+	FollowingChip      MessageID = "following-chip"
+	OtherDirectionChip MessageID = "other-direction-chip"
+	LineColumn         MessageID = "line-column"
+	DirectionColumn    MessageID = "direction-column"
+	DepartureColumn    MessageID = "departure-column"
+	StopListTitle      MessageID = "stop-list-title"
+
+	// Favourites
+	FavouriteSaved   MessageID = "favourite-saved"
+	NoFavouriteQuery MessageID = "no-favourite-query"
+	NoFavourite      MessageID = "no-favourite"
+	AnonymousUser    MessageID = "anonymous-user"
+
+	// Reminders
+	LeaveNow MessageID = "leave-now"
+)
+
+// catalogue holds the messages of every language. Positional arguments
+// let the translations order the arguments differently.
+var catalogue = map[Language]map[MessageID]Message{
+	French: {
+		InternalError: {Other: "Une erreur est survenue sur nos serveurs. Veuillez nous excuser pour ce contre-temps."},
+		Or:            {Other: "%s ou %s"},
+		And:           {Other: "%s et %s"},
+		Then:          {Other: ", puis %s"},
+		ThenSeparator: {Other: ", puis "},
+
+		AmbiguousStop:    {Other: "Voulez-vous dire %s ?"},
+		StopNotFound:     {Other: "Je ne trouve pas l'arrêt %s."},
+		LineNotFound:     {Other: "Je n'arrive pas à identifier la ligne correspondant à %s dans mon système."},
+		UnknownPosition:  {Other: "Je ne connais pas votre position. Veuillez préciser l'arrêt de départ."},
+		NoStopNearby:     {Other: "Je n'ai trouvé aucun arrêt près de vous."},
+		NoLineStopNearby: {Other: "Je n'ai trouvé aucun arrêt de la ligne %s près de vous."},
+
+		LineNotAtStop:       {Other: "La ligne %s ne semble pas s'arrêter à l'arrêt %s"},
+		LineUnavailable:     {Other: "Je ne peux fournir des informations concernant la ligne %s pour le moment."},
+		LineUpstream:        {Other: "La ligne %[1]s ne va pas de %[2]s vers %[3]s : l'arrêt %[3]s se trouve avant %[2]s sur son parcours."},
+		NoRoute:             {Other: "Aucune route en direction de %s n'a été trouvée pour la ligne %s"},
+		AfterTime:           {Other: " après %s"},
+		NoDeparture:         {Other: "Aucun départ n'a été trouvé sur la ligne %s en direction de %s%s"},
+		NoOtherDeparture:    {Other: "Je n'ai pas d'autre départ sur la ligne %s en direction de %s%s"},
+		NextDeparture:       {Other: "Le prochain bus %s en direction de %s%s partira %s depuis %s"},
+		FollowingDeparture:  {Other: "Le bus %s suivant en direction de %s%s partira %s depuis %s"},
+		NextDepartures:      {Other: "Les %d prochains bus %s en direction de %s%s partiront de %s %s."},
+		FollowingDepartures: {Other: "Les %d bus %s suivants en direction de %s%s partiront de %s %s."},
+		RouteTitle:          {Other: "Ligne %s → %s"},
+		NoLineUpstream:      {Other: "Aucune ligne ne va de %[1]s vers %[2]s : l'arrêt %[2]s se trouve avant %[1]s sur leur parcours."},
+		NoDirectLine:        {Other: "Aucune ligne directe ne relie %s à %s. Demandez-moi un itinéraire pour trouver une correspondance."},
+		NoDepartureTowards:  {Other: "Aucun départ n'a été trouvé de %s vers %s%s"},
+		AnyLineDeparture:    {Other: "Le prochain bus de %s vers %s%s est la ligne %s en direction de %s. Il partira %s."},
+		NoLineAtStop:        {Other: "Aucune ligne ne semble partir de l'arrêt %s"},
+		NoDepartureAtStop:   {Other: "Aucun départ n'a été trouvé depuis l'arrêt %s%s"},
+		BoardDeparture:      {Other: "la ligne %s en direction de %s %s"},
+		Board:               {Other: "Prochains départs depuis %s%s : %s."},
+		BoardTitle:          {Other: "Départs depuis %s"},
+
+		ScheduledDepartures:   {Other: "Les horaires en temps réel ne sont pas disponibles pour le moment, les horaires suivants peuvent ne pas être à jour."},
+		DeparturesUnavailable: {Other: "Les horaires en temps réel ne sont pas disponibles pour le moment, veuillez réessayer dans quelques instants."},
+
+		Clock:        {Other: "%dh%02d"},
+		AtClock:      {Other: "à %s"},
+		Now:          {Other: "maintenant"},
+		InOneMinute:  {Other: "dans une minute"},
+		In:           {Other: "dans %s"},
+		Minutes:      {One: "%s minute", Other: "%s minutes"},
+		ShortMinutes: {Other: "%d min"},
+
+		WalkLeg:           {Other: "marchez %s jusqu'à %s"},
+		FirstRideLeg:      {Other: "prenez la ligne %s en direction de %s jusqu'à %s"},
+		TimedFirstRideLeg: {Other: "prenez la ligne %s à %s en direction de %s jusqu'à %s"},
+		TomorrowRideLeg:   {Other: "prenez demain la ligne %s à %s en direction de %s jusqu'à %s"},
+		TransferLeg:       {Other: "changez pour la ligne %s en direction de %s jusqu'à %s"},
+		Journey:           {Other: "Depuis %s, %s."},
+		AlreadyThere:      {Other: "Vous êtes déjà à %s."},
+		NoItinerary:       {Other: "Je n'ai trouvé aucun itinéraire entre %s et %s."},
+
+		UnknownTime:           {Other: "Je n'ai pas compris à quelle heure vous souhaitez partir."},
+		UnknownCount:          {Other: "Je n'ai pas compris combien de départs vous souhaitez connaître."},
+		UnknownBus:            {Other: "De quel bus parlez-vous ? Précisez la ligne, l'arrêt de départ et la direction."},
+		UnknownStop:           {Other: "Je n'ai pas compris de quel arrêt vous parlez."},
+		NotUnderstoodQuestion: {Other: "Je n'ai pas compris. %s"},
+		RequestNotUnderstood:  {Other: "Je n'ai pas compris votre demande."},
+		Welcome:               {Other: "Bienvenue ! Demandez-moi par exemple quand passe le prochain bus 9 à Chauderon en direction de Lutry."},
+		Goodbye:               {Other: "Au revoir !"},
+		FollowUpReprompt:      {Other: "Voulez-vous le bus suivant ou celui dans l'autre sens ?"},
+
+		FollowingChip:      {Other: "Le suivant"},
+		OtherDirectionChip: {Other: "Autre direction"},
+		LineColumn:         {Other: "Ligne"},
+		DirectionColumn:    {Other: "Direction"},
+		DepartureColumn:    {Other: "Départ"},
+		StopListTitle:      {Other: "Arrêts"},
+
+		FavouriteSaved:   {Other: "C'est noté : votre trajet habituel est le bus %s de %s en direction de %s. Dites « mon bus » pour connaître ses prochains départs."},
+		NoFavouriteQuery: {Other: "Demandez-moi d'abord un départ, puis dites « enregistre mon trajet »."},
+		NoFavourite:      {Other: "Vous n'avez pas encore enregistré de trajet. Demandez-moi un départ, puis dites « enregistre mon trajet »."},
+		AnonymousUser:    {Other: "Je ne peux pas enregistrer de trajet sans savoir qui vous êtes."},
+
+		LeaveNow: {Other: "Partez maintenant pour le bus %s de %s en direction de %s."},
+	},
+	German: {
+		InternalError: {Other: "Auf unseren Servern ist ein Fehler aufgetreten. Bitte entschuldigen Sie die Unannehmlichkeiten."},
+		Or:            {Other: "%s oder %s"},
+		And:           {Other: "%s und %s"},
+		Then:          {Other: ", dann %s"},
+		ThenSeparator: {Other: ", dann "},
+
+		AmbiguousStop:    {Other: "Meinen Sie %s?"},
+		StopNotFound:     {Other: "Ich finde die Haltestelle %s nicht."},
+		LineNotFound:     {Other: "Ich kann keine Linie finden, die %s entspricht."},
+		UnknownPosition:  {Other: "Ich kenne Ihren Standort nicht. Bitte nennen Sie die Abfahrtshaltestelle."},
+		NoStopNearby:     {Other: "Ich habe keine Haltestelle in Ihrer Nähe gefunden."},
+		NoLineStopNearby: {Other: "Ich habe keine Haltestelle der Linie %s in Ihrer Nähe gefunden."},
+
+		LineNotAtStop:       {Other: "Die Linie %s scheint nicht an der Haltestelle %s zu halten"},
+		LineUnavailable:     {Other: "Ich kann im Moment keine Informationen zur Linie %s geben."},
+		LineUpstream:        {Other: "Die Linie %[1]s fährt nicht von %[2]s nach %[3]s: die Haltestelle %[3]s liegt auf ihrer Strecke vor %[2]s."},
+		NoRoute:             {Other: "Für die Linie %[2]s wurde keine Strecke Richtung %[1]s gefunden"},
+		AfterTime:           {Other: " nach %s"},
+		NoDeparture:         {Other: "Keine Abfahrt der Linie %s Richtung %s%s gefunden"},
+		NoOtherDeparture:    {Other: "Ich habe keine weitere Abfahrt der Linie %s Richtung %s%s"},
+		NextDeparture:       {Other: "Der nächste Bus %s Richtung %s%s fährt %s ab %s"},
+		FollowingDeparture:  {Other: "Der folgende Bus %s Richtung %s%s fährt %s ab %s"},
+		NextDepartures:      {Other: "Die %d nächsten Busse %s Richtung %s%s fahren ab %s %s."},
+		FollowingDepartures: {Other: "Die %d folgenden Busse %s Richtung %s%s fahren ab %s %s."},
+		RouteTitle:          {Other: "Linie %s → %s"},
+		NoLineUpstream:      {Other: "Keine Linie fährt von %[1]s nach %[2]s: die Haltestelle %[2]s liegt auf ihrer Strecke vor %[1]s."},
+		NoDirectLine:        {Other: "Keine direkte Linie verbindet %s mit %s. Fragen Sie mich nach einer Verbindung mit Umstieg."},
+		NoDepartureTowards:  {Other: "Keine Abfahrt von %s nach %s%s gefunden"},
+		AnyLineDeparture:    {Other: "Der nächste Bus von %s nach %s%s ist die Linie %s Richtung %s. Er fährt %s."},
+		NoLineAtStop:        {Other: "Keine Linie scheint von der Haltestelle %s abzufahren"},
+		NoDepartureAtStop:   {Other: "Keine Abfahrt ab der Haltestelle %s%s gefunden"},
+		BoardDeparture:      {Other: "die Linie %s Richtung %s %s"},
+		Board:               {Other: "Nächste Abfahrten ab %s%s: %s."},
+		BoardTitle:          {Other: "Abfahrten ab %s"},
+
+		ScheduledDepartures:   {Other: "Die Echtzeitdaten sind im Moment nicht verfügbar, die folgenden Zeiten sind möglicherweise nicht aktuell."},
+		DeparturesUnavailable: {Other: "Die Echtzeitdaten sind im Moment nicht verfügbar, bitte versuchen Sie es gleich noch einmal."},
+
+		Clock:        {Other: "%d:%02d Uhr"},
+		AtClock:      {Other: "um %s"},
+		Now:          {Other: "jetzt"},
+		InOneMinute:  {Other: "in einer Minute"},
+		In:           {Other: "in %s"},
+		Minutes:      {One: "%s Minute", Other: "%s Minuten"},
+		ShortMinutes: {Other: "%d Min."},
+
+		WalkLeg:           {Other: "gehen Sie %s zu Fuß bis %s"},
+		FirstRideLeg:      {Other: "nehmen Sie die Linie %s Richtung %s bis %s"},
+		TimedFirstRideLeg: {Other: "nehmen Sie die Linie %s um %s Richtung %s bis %s"},
+		TomorrowRideLeg:   {Other: "nehmen Sie morgen die Linie %s um %s Richtung %s bis %s"},
+		TransferLeg:       {Other: "steigen Sie in die Linie %s Richtung %s bis %s um"},
+		Journey:           {Other: "Ab %s %s."},
+		AlreadyThere:      {Other: "Sie sind bereits in %s."},
+		NoItinerary:       {Other: "Ich habe keine Verbindung zwischen %s und %s gefunden."},
+
+		UnknownTime:           {Other: "Ich habe nicht verstanden, um welche Uhrzeit Sie abfahren möchten."},
+		UnknownCount:          {Other: "Ich habe nicht verstanden, wie viele Abfahrten Sie wissen möchten."},
+		UnknownBus:            {Other: "Von welchem Bus sprechen Sie? Nennen Sie die Linie, die Abfahrtshaltestelle und die Richtung."},
+		UnknownStop:           {Other: "Ich habe nicht verstanden, von welcher Haltestelle Sie sprechen."},
+		NotUnderstoodQuestion: {Other: "Das habe ich nicht verstanden. %s"},
+		RequestNotUnderstood:  {Other: "Ich habe Ihre Anfrage nicht verstanden."},
+		Welcome:               {Other: "Willkommen! Fragen Sie mich zum Beispiel, wann der nächste Bus 9 ab Chauderon Richtung Lutry fährt."},
+		Goodbye:               {Other: "Auf Wiedersehen!"},
+		FollowUpReprompt:      {Other: "Möchten Sie den folgenden Bus oder den in die Gegenrichtung?"},
+
+		FollowingChip:      {Other: "Der nächste"},
+		OtherDirectionChip: {Other: "Andere Richtung"},
+		LineColumn:         {Other: "Linie"},
+		DirectionColumn:    {Other: "Richtung"},
+		DepartureColumn:    {Other: "Abfahrt"},
+		StopListTitle:      {Other: "Haltestellen"},
+
+		FavouriteSaved:   {Other: "Notiert: Ihre übliche Fahrt ist der Bus %s ab %s Richtung %s. Sagen Sie „mein Bus“, um die nächsten Abfahrten zu erfahren."},
+		NoFavouriteQuery: {Other: "Fragen Sie mich zuerst nach einer Abfahrt und sagen Sie dann „speichere meine Fahrt“."},
+		NoFavourite:      {Other: "Sie haben noch keine Fahrt gespeichert. Fragen Sie mich nach einer Abfahrt und sagen Sie dann „speichere meine Fahrt“."},
+		AnonymousUser:    {Other: "Ich kann keine Fahrt speichern, ohne zu wissen, wer Sie sind."},
+
+		LeaveNow: {Other: "Gehen Sie jetzt los, um den Bus %s um %s Richtung %s zu erreichen."},
+	},
+	Italian: {
+		InternalError: {Other: "Si è verificato un errore sui nostri server. Ci scusiamo per l'inconveniente."},
+		Or:            {Other: "%s o %s"},
+		And:           {Other: "%s e %s"},
+		Then:          {Other: ", poi %s"},
+		ThenSeparator: {Other: ", poi "},
+
+		AmbiguousStop:    {Other: "Intende %s?"},
+		StopNotFound:     {Other: "Non trovo la fermata %s."},
+		LineNotFound:     {Other: "Non riesco a identificare la linea corrispondente a %s."},
+		UnknownPosition:  {Other: "Non conosco la sua posizione. Indichi la fermata di partenza."},
+		NoStopNearby:     {Other: "Non ho trovato nessuna fermata vicino a lei."},
+		NoLineStopNearby: {Other: "Non ho trovato nessuna fermata della linea %s vicino a lei."},
+
+		LineNotAtStop:       {Other: "La linea %s non sembra fermarsi alla fermata %s"},
+		LineUnavailable:     {Other: "Al momento non posso fornire informazioni sulla linea %s."},
+		LineUpstream:        {Other: "La linea %[1]s non va da %[2]s verso %[3]s: la fermata %[3]s si trova prima di %[2]s sul suo percorso."},
+		NoRoute:             {Other: "Nessun percorso in direzione di %s è stato trovato per la linea %s"},
+		AfterTime:           {Other: " dopo le %s"},
+		NoDeparture:         {Other: "Nessuna partenza trovata sulla linea %s in direzione di %s%s"},
+		NoOtherDeparture:    {Other: "Non ho altre partenze sulla linea %s in direzione di %s%s"},
+		NextDeparture:       {Other: "Il prossimo autobus %s in direzione di %s%s partirà %s da %s"},
+		FollowingDeparture:  {Other: "L'autobus %s successivo in direzione di %s%s partirà %s da %s"},
+		NextDepartures:      {Other: "I %d prossimi autobus %s in direzione di %s%s partiranno da %s %s."},
+		FollowingDepartures: {Other: "I %d autobus %s successivi in direzione di %s%s partiranno da %s %s."},
+		RouteTitle:          {Other: "Linea %s → %s"},
+		NoLineUpstream:      {Other: "Nessuna linea va da %[1]s verso %[2]s: la fermata %[2]s si trova prima di %[1]s sul loro percorso."},
+		NoDirectLine:        {Other: "Nessuna linea diretta collega %s a %s. Mi chieda un itinerario per trovare una coincidenza."},
+		NoDepartureTowards:  {Other: "Nessuna partenza trovata da %s verso %s%s"},
+		AnyLineDeparture:    {Other: "Il prossimo autobus da %s verso %s%s è la linea %s in direzione di %s. Partirà %s."},
+		NoLineAtStop:        {Other: "Nessuna linea sembra partire dalla fermata %s"},
+		NoDepartureAtStop:   {Other: "Nessuna partenza trovata dalla fermata %s%s"},
+		BoardDeparture:      {Other: "la linea %s in direzione di %s %s"},
+		Board:               {Other: "Prossime partenze da %s%s: %s."},
+		BoardTitle:          {Other: "Partenze da %s"},
+
+		ScheduledDepartures:   {Other: "Gli orari in tempo reale non sono disponibili al momento, gli orari seguenti potrebbero non essere aggiornati."},
+		DeparturesUnavailable: {Other: "Gli orari in tempo reale non sono disponibili al momento, riprova tra qualche istante."},
+
+		Clock:        {Other: "%d:%02d"},
+		AtClock:      {Other: "alle %s"},
+		Now:          {Other: "ora"},
+		InOneMinute:  {Other: "tra un minuto"},
+		In:           {Other: "tra %s"},
+		Minutes:      {One: "%s minuto", Other: "%s minuti"},
+		ShortMinutes: {Other: "%d min"},
+
+		WalkLeg:           {Other: "cammini %s fino a %s"},
+		FirstRideLeg:      {Other: "prenda la linea %s in direzione di %s fino a %s"},
+		TimedFirstRideLeg: {Other: "prenda la linea %s alle %s in direzione di %s fino a %s"},
+		TomorrowRideLeg:   {Other: "prenda domani la linea %s alle %s in direzione di %s fino a %s"},
+		TransferLeg:       {Other: "cambi per la linea %s in direzione di %s fino a %s"},
+		Journey:           {Other: "Da %s, %s."},
+		AlreadyThere:      {Other: "È già a %s."},
+		NoItinerary:       {Other: "Non ho trovato nessun itinerario tra %s e %s."},
+
+		UnknownTime:           {Other: "Non ho capito a che ora vuole partire."},
+		UnknownCount:          {Other: "Non ho capito quante partenze vuole conoscere."},
+		UnknownBus:            {Other: "Di quale autobus parla? Indichi la linea, la fermata di partenza e la direzione."},
+		UnknownStop:           {Other: "Non ho capito di quale fermata parla."},
+		NotUnderstoodQuestion: {Other: "Non ho capito. %s"},
+		RequestNotUnderstood:  {Other: "Non ho capito la sua richiesta."},
+		Welcome:               {Other: "Benvenuto! Mi chieda per esempio quando passa il prossimo autobus 9 a Chauderon in direzione di Lutry."},
+		Goodbye:               {Other: "Arrivederci!"},
+		FollowUpReprompt:      {Other: "Vuole l'autobus successivo o quello nella direzione opposta?"},
+
+		FollowingChip:      {Other: "Il prossimo"},
+		OtherDirectionChip: {Other: "Altra direzione"},
+		LineColumn:         {Other: "Linea"},
+		DirectionColumn:    {Other: "Direzione"},
+		DepartureColumn:    {Other: "Partenza"},
+		StopListTitle:      {Other: "Fermate"},
+
+		FavouriteSaved:   {Other: "Fatto: il suo tragitto abituale è l'autobus %s da %s in direzione di %s. Dica «il mio autobus» per conoscere le prossime partenze."},
+		NoFavouriteQuery: {Other: "Mi chieda prima una partenza, poi dica «salva il mio tragitto»."},
+		NoFavourite:      {Other: "Non ha ancora salvato nessun tragitto. Mi chieda una partenza, poi dica «salva il mio tragitto»."},
+		AnonymousUser:    {Other: "Non posso salvare un tragitto senza sapere chi è."},
+
+		LeaveNow: {Other: "Parta adesso per l'autobus %s delle %s in direzione di %s."},
+	},
+	English: {
+		InternalError: {Other: "An error occurred on our servers. Sorry for the inconvenience."},
+		Or:            {Other: "%s or %s"},
+		And:           {Other: "%s and %s"},
+		Then:          {Other: ", then %s"},
+		ThenSeparator: {Other: ", then "},
+
+		AmbiguousStop:    {Other: "Do you mean %s?"},
+		StopNotFound:     {Other: "I can not find the stop %s."},
+		LineNotFound:     {Other: "I can not identify the line matching %s."},
+		UnknownPosition:  {Other: "I do not know where you are. Please tell me the departure stop."},
+		NoStopNearby:     {Other: "I found no stop near you."},
+		NoLineStopNearby: {Other: "I found no stop of line %s near you."},
+
+		LineNotAtStop:       {Other: "Line %s does not seem to stop at %s"},
+		LineUnavailable:     {Other: "I can not give information about line %s for the moment."},
+		LineUpstream:        {Other: "Line %[1]s does not go from %[2]s to %[3]s: the stop %[3]s comes before %[2]s on its route."},
+		NoRoute:             {Other: "No route towards %s has been found for line %s"},
+		AfterTime:           {Other: " after %s"},
+		NoDeparture:         {Other: "No departure found on line %s towards %s%s"},
+		NoOtherDeparture:    {Other: "I have no other departure on line %s towards %s%s"},
+		NextDeparture:       {Other: "The next bus %s towards %s%s leaves %s from %s"},
+		FollowingDeparture:  {Other: "The following bus %s towards %s%s leaves %s from %s"},
+		NextDepartures:      {Other: "The next %d buses %s towards %s%s leave from %s %s."},
+		FollowingDepartures: {Other: "The %d following buses %s towards %s%s leave from %s %s."},
+		RouteTitle:          {Other: "Line %s → %s"},
+		NoLineUpstream:      {Other: "No line goes from %[1]s to %[2]s: the stop %[2]s comes before %[1]s on their routes."},
+		NoDirectLine:        {Other: "No direct line links %s to %s. Ask me for an itinerary to find a connection."},
+		NoDepartureTowards:  {Other: "No departure found from %s to %s%s"},
+		AnyLineDeparture:    {Other: "The next bus from %s to %s%s is line %s towards %s. It leaves %s."},
+		NoLineAtStop:        {Other: "No line seems to leave from %s"},
+		NoDepartureAtStop:   {Other: "No departure found from %s%s"},
+		BoardDeparture:      {Other: "line %s towards %s %s"},
+		Board:               {Other: "Next departures from %s%s: %s."},
+		BoardTitle:          {Other: "Departures from %s"},
+
+		ScheduledDepartures:   {Other: "Real time departures are not available at the moment, the following times may not be up to date."},
+		DeparturesUnavailable: {Other: "Real time departures are not available at the moment, please try again in a few moments."},
+
+		Clock:        {Other: "%d:%02d"},
+		AtClock:      {Other: "at %s"},
+		Now:          {Other: "now"},
+		InOneMinute:  {Other: "in one minute"},
+		In:           {Other: "in %s"},
+		Minutes:      {One: "%s minute", Other: "%s minutes"},
+		ShortMinutes: {Other: "%d min"},
+
+		WalkLeg:           {Other: "walk %s to %s"},
+		FirstRideLeg:      {Other: "take line %s towards %s to %s"},
+		TimedFirstRideLeg: {Other: "take line %s at %s towards %s to %s"},
+		TomorrowRideLeg:   {Other: "take line %s tomorrow at %s towards %s to %s"},
+		TransferLeg:       {Other: "change to line %s towards %s to %s"},
+		Journey:           {Other: "From %s, %s."},
+		AlreadyThere:      {Other: "You are already at %s."},
+		NoItinerary:       {Other: "I found no itinerary between %s and %s."},
+
+		UnknownTime:           {Other: "I did not understand when you want to leave."},
+		UnknownCount:          {Other: "I did not understand how many departures you want to know."},
+		UnknownBus:            {Other: "Which bus are you talking about? Tell me the line, the departure stop and the direction."},
+		UnknownStop:           {Other: "I did not understand which stop you mean."},
+		NotUnderstoodQuestion: {Other: "I did not understand. %s"},
+		RequestNotUnderstood:  {Other: "I did not understand your request."},
+		Welcome:               {Other: "Welcome! Ask me for example when the next bus 9 leaves Chauderon towards Lutry."},
+		Goodbye:               {Other: "Goodbye!"},
+		FollowUpReprompt:      {Other: "Would you like the following bus or the one in the other direction?"},
+
+		FollowingChip:      {Other: "The next one"},
+		OtherDirectionChip: {Other: "Other direction"},
+		LineColumn:         {Other: "Line"},
+		DirectionColumn:    {Other: "Direction"},
+		DepartureColumn:    {Other: "Departure"},
+		StopListTitle:      {Other: "Stops"},
+
+		FavouriteSaved:   {Other: "Got it: your usual trip is bus %s from %s towards %s. Say \"my bus\" to hear its next departures."},
+		NoFavouriteQuery: {Other: "Ask me for a departure first, then say \"save my trip\"."},
+		NoFavourite:      {Other: "You have not saved any trip yet. Ask me for a departure, then say \"save my trip\"."},
+		AnonymousUser:    {Other: "I can not save a trip without knowing who you are."},
+
+		LeaveNow: {Other: "Leave now for bus %s at %s towards %s."},
+	},
+}
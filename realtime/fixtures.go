@@ -0,0 +1,149 @@
+package realtime
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"log"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/gophersch/tlgo"
+)
+
+var (
+	// ErrNoFixture is returned for the departures never recorded
+	ErrNoFixture = errors.New("No fixture for the departures")
+)
+
+// Fixture is the recorded departures of a line at a stop, e.g.
+// {"stopId": "…", "lineId": "…", "wayback": false,
+// "waitingTimes": ["2m10s", "9m", "17m"]}
+type Fixture struct {
+	StopID       string   `json:"stopId"`
+	LineID       string   `json:"lineId"`
+	Wayback      bool     `json:"wayback"`
+	WaitingTimes []string `json:"waitingTimes"`
+}
+
+type fixtureKey struct {
+	stopID  string
+	lineID  string
+	wayback bool
+}
+
+func (f Fixture) key() fixtureKey {
+	return fixtureKey{stopID: f.StopID, lineID: f.LineID, wayback: f.Wayback}
+}
+
+// FixtureProvider replays recorded departures. The waiting times being
+// relative to the asked time, the answers are the same whenever they are
+// replayed.
+type FixtureProvider struct {
+	fixtures map[fixtureKey][]tlgo.Journey
+}
+
+// LoadFixtures reads a JSON list of fixtures
+func LoadFixtures(r io.Reader) (*FixtureProvider, error) {
+
+	fixtures := []Fixture{}
+	if err := json.NewDecoder(r).Decode(&fixtures); err != nil {
+		return nil, err
+	}
+
+	provider := &FixtureProvider{fixtures: map[fixtureKey][]tlgo.Journey{}}
+	for _, fixture := range fixtures {
+		journeys := make([]tlgo.Journey, len(fixture.WaitingTimes))
+		for i, waitingTime := range fixture.WaitingTimes {
+			d, err := time.ParseDuration(waitingTime)
+			if err != nil {
+				return nil, err
+			}
+			journeys[i] = tlgo.Journey{WaitingTime: d}
+		}
+		provider.fixtures[fixture.key()] = journeys
+	}
+	return provider, nil
+}
+
+// OpenFixtures reads the fixtures of a file
+func OpenFixtures(path string) (*FixtureProvider, error) {
+
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	return LoadFixtures(file)
+}
+
+func (p *FixtureProvider) StopDepartures(ctx context.Context, stopID string, lineID string, at time.Time, wayback bool) ([]tlgo.Journey, error) {
+
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	journeys, hasJourneys := p.fixtures[fixtureKey{stopID: stopID, lineID: lineID, wayback: wayback}]
+	if !hasJourneys {
+		return nil, ErrNoFixture
+	}
+	return append([]tlgo.Journey{}, journeys...), nil
+}
+
+// Recorder saves the departures of a provider as fixtures, the file being
+// rewritten after each new recording.
+type Recorder struct {
+	provider DeparturesProvider
+	path     string
+
+	mu       sync.Mutex
+	fixtures []Fixture
+	indexes  map[fixtureKey]int
+}
+
+// NewRecorder returns a provider recording the departures in the file
+func NewRecorder(provider DeparturesProvider, path string) *Recorder {
+	return &Recorder{provider: provider, path: path, indexes: map[fixtureKey]int{}}
+}
+
+func (r *Recorder) StopDepartures(ctx context.Context, stopID string, lineID string, at time.Time, wayback bool) ([]tlgo.Journey, error) {
+
+	journeys, err := r.provider.StopDepartures(ctx, stopID, lineID, at, wayback)
+	if err != nil {
+		return nil, err
+	}
+
+	fixture := Fixture{StopID: stopID, LineID: lineID, Wayback: wayback, WaitingTimes: make([]string, len(journeys))}
+	for i, journey := range journeys {
+		fixture.WaitingTimes[i] = journey.WaitingTime.String()
+	}
+
+	// The departures are still answered when they can not be recorded
+	if err := r.record(fixture); err != nil {
+		log.Printf("Can not record the departures of stop %s: %v\n", stopID, err)
+	}
+	return journeys, nil
+}
+
+// record keeps the last departures of each line at each stop
+func (r *Recorder) record(fixture Fixture) error {
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if i, hasIndex := r.indexes[fixture.key()]; hasIndex {
+		r.fixtures[i] = fixture
+	} else {
+		r.indexes[fixture.key()] = len(r.fixtures)
+		r.fixtures = append(r.fixtures, fixture)
+	}
+
+	b, err := json.MarshalIndent(r.fixtures, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(r.path, b, 0644)
+}
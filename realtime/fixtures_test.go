@@ -0,0 +1,98 @@
+package realtime
+
+import (
+	"context"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/gophersch/tlgo"
+)
+
+const (
+	belAir      = "1970329131941902"
+	stFrancois  = "1970329131942273"
+	line1       = "11821953316814849"
+	line9       = "11821953316814886"
+	fixturePath = "testdata/departures.json"
+)
+
+func TestFixtureProviderReplays(t *testing.T) {
+
+	provider, err := OpenFixtures(fixturePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		stopID  string
+		lineID  string
+		wayback bool
+		want    []time.Duration
+	}{
+		{belAir, line1, false, []time.Duration{102 * time.Second, 522 * time.Second, 942 * time.Second}},
+		{belAir, line1, true, []time.Duration{258 * time.Second, 678 * time.Second, 1098 * time.Second}},
+		{stFrancois, line9, false, []time.Duration{185 * time.Second, 545 * time.Second, 905 * time.Second, 1265 * time.Second}},
+		{stFrancois, line9, true, []time.Duration{}},
+	}
+
+	// The waiting times do not depend on the asked time
+	for _, at := range []time.Time{time.Now(), time.Date(2026, 10, 18, 23, 50, 0, 0, time.UTC)} {
+		for _, test := range tests {
+			journeys, err := provider.StopDepartures(context.Background(), test.stopID, test.lineID, at, test.wayback)
+			if err != nil {
+				t.Fatalf("%s %s %v: %v", test.stopID, test.lineID, test.wayback, err)
+			}
+
+			got := make([]time.Duration, len(journeys))
+			for i, journey := range journeys {
+				got[i] = journey.WaitingTime
+			}
+			if !reflect.DeepEqual(got, test.want) {
+				t.Errorf("%s %s %v: expected %v, got %v", test.stopID, test.lineID, test.wayback, test.want, got)
+			}
+		}
+	}
+
+	if _, err := provider.StopDepartures(context.Background(), stFrancois, line1, time.Now(), false); err != ErrNoFixture {
+		t.Errorf("expected %v, got %v", ErrNoFixture, err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := provider.StopDepartures(ctx, belAir, line1, time.Now(), false); err != context.Canceled {
+		t.Errorf("expected %v, got %v", context.Canceled, err)
+	}
+}
+
+func TestRecorderRoundTrip(t *testing.T) {
+
+	replayed, err := OpenFixtures(fixturePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	path := filepath.Join(t.TempDir(), "departures.json")
+	recorder := NewRecorder(replayed, path)
+
+	want := map[fixtureKey][]tlgo.Journey{}
+	for _, key := range []fixtureKey{{belAir, line1, false}, {belAir, line1, true}, {stFrancois, line9, false}} {
+		// Recording twice the same departures keeps a single fixture
+		for i := 0; i < 2; i++ {
+			journeys, err := recorder.StopDepartures(context.Background(), key.stopID, key.lineID, time.Now(), key.wayback)
+			if err != nil {
+				t.Fatal(err)
+			}
+			want[key] = journeys
+		}
+	}
+
+	recorded, err := OpenFixtures(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(recorded.fixtures, want) {
+		t.Errorf("expected %v, got %v", want, recorded.fixtures)
+	}
+}
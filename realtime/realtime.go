@@ -0,0 +1,50 @@
+// Package realtime provides the real time departures of the network, from
+// the TL API or from recorded fixtures replayed without the network.
+package realtime
+
+import (
+	"context"
+	"time"
+
+	"github.com/gophersch/tlgo"
+)
+
+// DeparturesProvider lists the real time departures of a line at a stop
+type DeparturesProvider interface {
+	// StopDepartures returns the departures after the time, the wayback
+	// selecting the direction of the line
+	StopDepartures(ctx context.Context, stopID string, lineID string, at time.Time, wayback bool) ([]tlgo.Journey, error)
+}
+
+// TLProvider asks the departures to the TL API
+type TLProvider struct {
+	client *tlgo.Client
+}
+
+// NewTLProvider returns a provider asking the departures with the client
+func NewTLProvider(client *tlgo.Client) *TLProvider {
+	return &TLProvider{client: client}
+}
+
+type departuresResult struct {
+	journeys []tlgo.Journey
+	err      error
+}
+
+// StopDepartures returns as soon as the context is done. The client not
+// being cancellable, its request still runs to completion in background.
+func (p *TLProvider) StopDepartures(ctx context.Context, stopID string, lineID string, at time.Time, wayback bool) ([]tlgo.Journey, error) {
+
+	results := make(chan departuresResult, 1)
+	go func() {
+		journeys, err := p.client.ListStopDepartures(stopID, lineID, at, wayback)
+		results <- departuresResult{journeys: journeys, err: err}
+	}()
+
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case result := <-results:
+		return result.journeys, result.err
+	}
+}
@@ -0,0 +1,22 @@
+package reminders
+
+import "time"
+
+// Clock tells the time to the scheduler, it is faked to run the
+// reminders of a whole morning in a few milliseconds.
+type Clock interface {
+	Now() time.Time
+	// After sends the time once the duration has elapsed
+	After(d time.Duration) <-chan time.Time
+}
+
+// SystemClock is the clock of the machine
+type SystemClock struct{}
+
+func (SystemClock) Now() time.Time {
+	return time.Now()
+}
+
+func (SystemClock) After(d time.Duration) <-chan time.Time {
+	return time.After(d)
+}
@@ -0,0 +1,59 @@
+package reminders
+
+import (
+	"sync"
+	"time"
+)
+
+// fakeClock is a clock moving only when it is told to
+type fakeClock struct {
+	mu      sync.Mutex
+	now     time.Time
+	waiters []fakeWaiter
+}
+
+type fakeWaiter struct {
+	at time.Time
+	c  chan time.Time
+}
+
+// newFakeClock returns a clock stopped at the time
+func newFakeClock(now time.Time) *fakeClock {
+	return &fakeClock{now: now}
+}
+
+func (c *fakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *fakeClock) After(d time.Duration) <-chan time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	waiter := fakeWaiter{at: c.now.Add(d), c: make(chan time.Time, 1)}
+	if d <= 0 {
+		waiter.c <- c.now
+		return waiter.c
+	}
+	c.waiters = append(c.waiters, waiter)
+	return waiter.c
+}
+
+// Advance moves the clock forward, firing the elapsed waiters
+func (c *fakeClock) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.now = c.now.Add(d)
+	pending := c.waiters[:0]
+	for _, waiter := range c.waiters {
+		if waiter.at.After(c.now) {
+			pending = append(pending, waiter)
+			continue
+		}
+		waiter.c <- c.now
+	}
+	c.waiters = pending
+}
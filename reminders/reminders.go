@@ -0,0 +1,275 @@
+// Package reminders tells the users when to leave for the bus of their
+// favourite departure. The departures are polled during the time window of
+// each reminder and a notification is sent once the walk to the stop
+// should start.
+package reminders
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/yageek/tl-ai/assistant"
+	"github.com/yageek/tl-ai/favourites"
+	"github.com/yageek/tl-ai/i18n"
+)
+
+// Departures answers the departure queries, it is implemented by the assistant
+type Departures interface {
+	NextDepartures(ctx context.Context, q assistant.NextDepartureQuery) (assistant.NextDepartureAnswer, error)
+}
+
+// Reminder asks to be told when to leave for the favourite departure of
+// a user taken between Start and End.
+type Reminder struct {
+	ID     string
+	UserID string
+	// Start and End are the times of the day between which the user
+	// takes the bus
+	Start time.Duration
+	End   time.Duration
+	// Days are the days of the reminder, every day when empty
+	Days []time.Weekday
+	// Walking is the time needed to walk to the stop
+	Walking  time.Duration
+	Language i18n.Language
+}
+
+// window returns the times of the day of the reminder
+func (r Reminder) window(now time.Time) (time.Time, time.Time) {
+	midnight := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	return midnight.Add(r.Start), midnight.Add(r.End)
+}
+
+func (r Reminder) isDay(day time.Weekday) bool {
+	if len(r.Days) == 0 {
+		return true
+	}
+	for _, d := range r.Days {
+		if d == day {
+			return true
+		}
+	}
+	return false
+}
+
+// Notification tells a user to leave for a departure
+type Notification struct {
+	ReminderID string               `json:"reminderId"`
+	UserID     string               `json:"userId"`
+	Language   i18n.Language        `json:"language"`
+	Departure  time.Time            `json:"departure"`
+	LeaveAt    time.Time            `json:"leaveAt"`
+	Favourite  favourites.Favourite `json:"favourite"`
+	Text       string               `json:"text"`
+}
+
+// Scheduler polls the departures of the reminders and notifies the users
+type Scheduler struct {
+	departures Departures
+	favourites favourites.Store
+	sink       Sink
+	clock      Clock
+	// interval is the time between two polls
+	interval time.Duration
+
+	mu        sync.Mutex
+	reminders map[string]Reminder
+	// notified is the last departure notified for each reminder
+	notified map[string]time.Time
+}
+
+// NewScheduler returns a scheduler polling the departures at the interval
+func NewScheduler(departures Departures, store favourites.Store, sink Sink, clock Clock, interval time.Duration) *Scheduler {
+	return &Scheduler{
+		departures: departures,
+		favourites: store,
+		sink:       sink,
+		clock:      clock,
+		interval:   interval,
+		reminders:  map[string]Reminder{},
+		notified:   map[string]time.Time{},
+	}
+}
+
+// Add schedules the reminder, replacing the one with the same ID
+func (s *Scheduler) Add(reminder Reminder) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.reminders[reminder.ID] = reminder
+}
+
+// Remove unschedules the reminder
+func (s *Scheduler) Remove(id string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.reminders, id)
+	delete(s.notified, id)
+}
+
+// Run polls the departures until the context is done
+func (s *Scheduler) Run(ctx context.Context) {
+	for {
+		s.Tick(ctx)
+		select {
+		case <-ctx.Done():
+			return
+		case <-s.clock.After(s.interval):
+		}
+	}
+}
+
+// Tick checks the reminders once
+func (s *Scheduler) Tick(ctx context.Context) {
+
+	now := s.clock.Now().In(assistant.TimeZone)
+
+	s.mu.Lock()
+	active := []Reminder{}
+	for _, reminder := range s.reminders {
+		start, end := reminder.window(now)
+		// The polls start early enough to tell to leave for the first departures
+		if reminder.isDay(now.Weekday()) && !now.Before(start.Add(-reminder.Walking-s.interval)) && now.Before(end) {
+			active = append(active, reminder)
+		}
+	}
+	s.mu.Unlock()
+
+	// A stalled poll must not delay the next one
+	ctx, cancel := context.WithTimeout(ctx, s.interval)
+	defer cancel()
+
+	for _, reminder := range active {
+		if err := s.check(ctx, reminder, now); err != nil {
+			log.Printf("Can not check the reminder %s: %v\n", reminder.ID, err)
+		}
+	}
+}
+
+// check notifies the user when the walk to the stop for one of the next
+// departures of the window should start before the next poll.
+func (s *Scheduler) check(ctx context.Context, reminder Reminder, now time.Time) error {
+
+	favourite, err := s.favourites.Get(reminder.UserID)
+	if err != nil {
+		return err
+	}
+
+	answer, err := s.departures.NextDepartures(ctx, assistant.NextDepartureQuery{
+		Line:      favourite.Line,
+		Origin:    favourite.Origin,
+		Direction: favourite.Direction,
+		Count:     assistant.MaxDeparturesCount,
+		Language:  reminder.Language,
+	})
+	if err != nil {
+		return err
+	}
+
+	start, end := reminder.window(now)
+	for _, departure := range answer.Departures {
+
+		if departure.Time.Before(start) || departure.Time.After(end) {
+			continue
+		}
+
+		leaveAt := departure.Time.Add(-reminder.Walking)
+		if leaveAt.Before(now) {
+			// Too late to walk to this one
+			continue
+		}
+		if !leaveAt.Before(now.Add(s.interval)) {
+			// Told at a next poll
+			return nil
+		}
+
+		s.mu.Lock()
+		isNotified := s.notified[reminder.ID].Equal(departure.Time)
+		s.notified[reminder.ID] = departure.Time
+		s.mu.Unlock()
+		if isNotified {
+			return nil
+		}
+
+		return s.sink.Notify(ctx, Notification{
+			ReminderID: reminder.ID,
+			UserID:     reminder.UserID,
+			Language:   reminder.Language,
+			Departure:  departure.Time,
+			LeaveAt:    leaveAt,
+			Favourite:  favourite,
+			Text:       leaveText(reminder.Language, departure),
+		})
+	}
+	return nil
+}
+
+func leaveText(language i18n.Language, departure assistant.Departure) string {
+	p := i18n.NewPrinter(language)
+	t := departure.Time.In(assistant.TimeZone)
+	clock := p.Sprintf(i18n.Clock, t.Hour(), t.Minute())
+	return p.Sprintf(i18n.LeaveNow, departure.Line.ShortName, clock, departure.Destination)
+}
+
+// reminderConfig is a reminder as configured in JSON, e.g.
+// {"id": "r1", "userId": "…", "start": "07:30", "end": "08:30",
+// "days": [1, 2, 3, 4, 5], "walking": "5m", "language": "fr"}
+type reminderConfig struct {
+	ID       string         `json:"id"`
+	UserID   string         `json:"userId"`
+	Start    string         `json:"start"`
+	End      string         `json:"end"`
+	Days     []time.Weekday `json:"days"`
+	Walking  string         `json:"walking"`
+	Language string         `json:"language"`
+}
+
+// LoadReminders reads a JSON list of reminders
+func LoadReminders(r io.Reader) ([]Reminder, error) {
+
+	configs := []reminderConfig{}
+	if err := json.NewDecoder(r).Decode(&configs); err != nil {
+		return nil, err
+	}
+
+	reminders := make([]Reminder, len(configs))
+	for i, config := range configs {
+
+		start, err := timeOfDay(config.Start)
+		if err != nil {
+			return nil, fmt.Errorf("invalid start of reminder %s: %v", config.ID, err)
+		}
+		end, err := timeOfDay(config.End)
+		if err != nil {
+			return nil, fmt.Errorf("invalid end of reminder %s: %v", config.ID, err)
+		}
+		walking, err := time.ParseDuration(config.Walking)
+		if err != nil && config.Walking != "" {
+			return nil, fmt.Errorf("invalid walking time of reminder %s: %v", config.ID, err)
+		}
+
+		reminders[i] = Reminder{
+			ID:       config.ID,
+			UserID:   config.UserID,
+			Start:    start,
+			End:      end,
+			Days:     config.Days,
+			Walking:  walking,
+			Language: i18n.ParseLanguage(config.Language),
+		}
+	}
+	return reminders, nil
+}
+
+// timeOfDay parses a time such as "07:30" as the duration since midnight
+func timeOfDay(value string) (time.Duration, error) {
+	t, err := time.Parse("15:04", value)
+	if err != nil {
+		return 0, err
+	}
+	return time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute, nil
+}
@@ -0,0 +1,198 @@
+package reminders
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gophersch/tlgo"
+	"github.com/yageek/tl-ai/assistant"
+	"github.com/yageek/tl-ai/favourites"
+	"github.com/yageek/tl-ai/i18n"
+)
+
+// timetable answers the departures following the time of the clock
+type timetable struct {
+	clock      Clock
+	departures []time.Time
+}
+
+func (t timetable) NextDepartures(ctx context.Context, q assistant.NextDepartureQuery) (assistant.NextDepartureAnswer, error) {
+	answer := assistant.NextDepartureAnswer{}
+	for _, departure := range t.departures {
+		if departure.After(t.clock.Now()) && len(answer.Departures) < q.Count {
+			answer.Departures = append(answer.Departures, assistant.Departure{
+				Line:        tlgo.Line{ShortName: q.Line},
+				Time:        departure,
+				Destination: q.Direction,
+			})
+		}
+	}
+	return answer, nil
+}
+
+func at(hour, minute int) time.Time {
+	return time.Date(2026, 10, 19, hour, minute, 0, 0, assistant.TimeZone)
+}
+
+// newTestScheduler polls every minute the departures of line 2 from Flon
+// to Gare at 8:00, 8:10 and 8:40, the clock starting at 7:30 on Monday.
+func newTestScheduler(t *testing.T) (*Scheduler, *fakeClock, *fakeSink) {
+	t.Helper()
+
+	store := favourites.NewMemoryStore()
+	if err := store.Put("alice", favourites.Favourite{Line: "2", Origin: "Flon", Direction: "Gare"}); err != nil {
+		t.Fatal(err)
+	}
+
+	clock := newFakeClock(at(7, 30))
+	sink := &fakeSink{}
+	departures := timetable{clock: clock, departures: []time.Time{at(8, 0), at(8, 10), at(8, 40)}}
+	return NewScheduler(departures, store, sink, clock, time.Minute), clock, sink
+}
+
+// morning ticks every minute until 9:00
+func morning(s *Scheduler, clock *fakeClock) {
+	for clock.Now().Before(at(9, 0)) {
+		s.Tick(context.Background())
+		clock.Advance(time.Minute)
+	}
+}
+
+func TestReminders(t *testing.T) {
+
+	s, clock, sink := newTestScheduler(t)
+	s.Add(Reminder{
+		ID:       "commute",
+		UserID:   "alice",
+		Start:    7*time.Hour + 55*time.Minute,
+		End:      8*time.Hour + 30*time.Minute,
+		Walking:  5 * time.Minute,
+		Language: i18n.English,
+	})
+	morning(s, clock)
+
+	// The departure at 8:40 is after the window
+	notifications := sink.Notifications()
+	want := []struct {
+		departure time.Time
+		leaveAt   time.Time
+	}{
+		{at(8, 0), at(7, 55)},
+		{at(8, 10), at(8, 5)},
+	}
+	if len(notifications) != len(want) {
+		t.Fatalf("expected %d notifications, got %+v", len(want), notifications)
+	}
+
+	for i, notification := range notifications {
+		if !notification.Departure.Equal(want[i].departure) || !notification.LeaveAt.Equal(want[i].leaveAt) {
+			t.Errorf("expected to leave at %s for %s, got %s for %s", want[i].leaveAt, want[i].departure, notification.LeaveAt, notification.Departure)
+		}
+		if notification.UserID != "alice" || notification.ReminderID != "commute" {
+			t.Errorf("expected the reminder of alice, got %+v", notification)
+		}
+	}
+
+	if text := "Leave now for bus 2 at 8:00 towards Gare."; notifications[0].Text != text {
+		t.Errorf("expected %q, got %q", text, notifications[0].Text)
+	}
+}
+
+func TestRemindersAreSkipped(t *testing.T) {
+
+	tests := []struct {
+		name     string
+		reminder Reminder
+	}{
+		{"other days", Reminder{ID: "weekend", UserID: "alice", Start: 7 * time.Hour, End: 9 * time.Hour, Days: []time.Weekday{time.Saturday, time.Sunday}}},
+		{"no favourite", Reminder{ID: "bob", UserID: "bob", Start: 7 * time.Hour, End: 9 * time.Hour}},
+		// The walk to the first departure should have started before the clock
+		{"too late", Reminder{ID: "late", UserID: "alice", Start: 7*time.Hour + 30*time.Minute, End: 8*time.Hour + 5*time.Minute, Walking: 45 * time.Minute}},
+	}
+
+	for _, test := range tests {
+		s, clock, sink := newTestScheduler(t)
+		s.Add(test.reminder)
+		morning(s, clock)
+
+		if notifications := sink.Notifications(); len(notifications) != 0 {
+			t.Errorf("%s: expected no notification, got %+v", test.name, notifications)
+		}
+	}
+
+	// The removed reminders are not told anymore
+	s, clock, sink := newTestScheduler(t)
+	s.Add(Reminder{ID: "commute", UserID: "alice", Start: 7 * time.Hour, End: 9 * time.Hour})
+	s.Remove("commute")
+	morning(s, clock)
+	if notifications := sink.Notifications(); len(notifications) != 0 {
+		t.Errorf("expected no notification, got %+v", notifications)
+	}
+}
+
+func TestSchedulerRun(t *testing.T) {
+
+	s, clock, sink := newTestScheduler(t)
+	s.Add(Reminder{ID: "commute", UserID: "alice", Start: 7*time.Hour + 55*time.Minute, End: 8*time.Hour + 5*time.Minute, Walking: 5 * time.Minute})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		s.Run(ctx)
+		close(done)
+	}()
+
+	// Each tick waits for the clock before the next one
+	deadline := time.After(5 * time.Second)
+	for len(sink.Notifications()) == 0 {
+		select {
+		case <-deadline:
+			t.Fatalf("expected a notification, the clock is at %s", clock.Now())
+		case <-time.After(time.Millisecond):
+			if clock.Now().Before(at(9, 0)) {
+				clock.Advance(time.Minute)
+			}
+		}
+	}
+
+	cancel()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("expected the scheduler to stop")
+	}
+}
+
+func TestLoadReminders(t *testing.T) {
+
+	reminders, err := LoadReminders(strings.NewReader(`[
+		{"id": "r1", "userId": "alice", "start": "07:30", "end": "08:30", "days": [1, 2, 3, 4, 5], "walking": "5m", "language": "de-CH"},
+		{"id": "r2", "userId": "bob", "start": "17:00", "end": "18:00"}
+	]`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(reminders) != 2 {
+		t.Fatalf("expected 2 reminders, got %d", len(reminders))
+	}
+	first := reminders[0]
+	if first.Start != 7*time.Hour+30*time.Minute || first.End != 8*time.Hour+30*time.Minute || first.Walking != 5*time.Minute || first.Language != i18n.German || len(first.Days) != 5 {
+		t.Errorf("unexpected reminder %+v", first)
+	}
+	if second := reminders[1]; second.Walking != 0 || second.Language != i18n.DefaultLanguage || len(second.Days) != 0 {
+		t.Errorf("unexpected reminder %+v", second)
+	}
+
+	for _, invalid := range []string{
+		`[{"id": "r1", "start": "7h30", "end": "08:30"}]`,
+		`[{"id": "r1", "start": "07:30", "end": "08:30", "walking": "five minutes"}]`,
+		`{"id": "r1"}`,
+	} {
+		if _, err := LoadReminders(strings.NewReader(invalid)); err == nil {
+			t.Errorf("expected %s to be invalid", invalid)
+		}
+	}
+}
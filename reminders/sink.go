@@ -0,0 +1,137 @@
+package reminders
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"golang.org/x/oauth2"
+)
+
+const (
+	// actionsSendURL is the endpoint of the Actions on Google notifications
+	actionsSendURL = "https://actions.googleapis.com/v2/conversations:send"
+	// ActionsScope is the OAuth scope required to send the notifications
+	ActionsScope = "https://www.googleapis.com/auth/actions.fulfillment.conversation"
+	// webhookTimeout bounds the posts of the default client of WebhookSink
+	webhookTimeout = 10 * time.Second
+)
+
+// Sink delivers the notifications to the users. The context is the one
+// of the tick checking the reminders.
+type Sink interface {
+	Notify(ctx context.Context, notification Notification) error
+}
+
+// LogSink only logs the notifications
+type LogSink struct{}
+
+func (LogSink) Notify(ctx context.Context, notification Notification) error {
+	log.Printf("Notification for %s: %s\n", notification.UserID, notification.Text)
+	return nil
+}
+
+// WebhookSink posts the notifications as JSON to a URL
+type WebhookSink struct {
+	URL string
+	// Client posts the notifications, a client with a timeout is used when nil
+	Client *http.Client
+}
+
+func (s WebhookSink) Notify(ctx context.Context, notification Notification) error {
+
+	body, err := json.Marshal(notification)
+	if err != nil {
+		return err
+	}
+
+	client := s.Client
+	if client == nil {
+		client = &http.Client{Timeout: webhookTimeout}
+	}
+
+	resp, err := post(ctx, client, s.URL, body)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode/100 != 2 {
+		return fmt.Errorf("the webhook answered %s", resp.Status)
+	}
+	return nil
+}
+
+// ActionsSink sends the notifications through Actions on Google. Tapping
+// the notification opens the intent, e.g. the one telling the favourite.
+type ActionsSink struct {
+	Intent      string
+	TokenSource oauth2.TokenSource
+}
+
+type actionsPushMessage struct {
+	CustomPushMessage actionsCustomPushMessage `json:"customPushMessage"`
+}
+
+type actionsCustomPushMessage struct {
+	UserNotification actionsUserNotification `json:"userNotification"`
+	Target           actionsTarget           `json:"target"`
+}
+
+type actionsUserNotification struct {
+	Title string `json:"title"`
+}
+
+type actionsTarget struct {
+	UserID string `json:"userId"`
+	Intent string `json:"intent"`
+	Locale string `json:"locale"`
+}
+
+func (s ActionsSink) Notify(ctx context.Context, notification Notification) error {
+
+	// The users of the other platforms can not be notified by Google
+	if strings.Contains(notification.UserID, "/") {
+		log.Printf("The user %s can not be notified through Actions on Google\n", notification.UserID)
+		return nil
+	}
+
+	body, err := json.Marshal(actionsPushMessage{CustomPushMessage: actionsCustomPushMessage{
+		UserNotification: actionsUserNotification{Title: notification.Text},
+		Target: actionsTarget{
+			UserID: notification.UserID,
+			Intent: s.Intent,
+			Locale: string(notification.Language),
+		},
+	}})
+	if err != nil {
+		return err
+	}
+
+	client := oauth2.NewClient(ctx, s.TokenSource)
+	resp, err := post(ctx, client, actionsSendURL, body)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode/100 != 2 {
+		return fmt.Errorf("Actions on Google answered %s", resp.Status)
+	}
+	return nil
+}
+
+// post sends the JSON body to the URL within the context
+func post(ctx context.Context, client *http.Client, url string, body []byte) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	return client.Do(req)
+}
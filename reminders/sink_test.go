@@ -0,0 +1,67 @@
+package reminders
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+)
+
+func TestWebhookSink(t *testing.T) {
+
+	received := make(chan Notification, 1)
+	status := http.StatusNoContent
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		notification := Notification{}
+		if err := json.NewDecoder(r.Body).Decode(&notification); err != nil {
+			t.Errorf("invalid notification: %v", err)
+		}
+		received <- notification
+		w.WriteHeader(status)
+	}))
+	defer server.Close()
+
+	sink := WebhookSink{URL: server.URL, Client: server.Client()}
+	sent := Notification{ReminderID: "commute", UserID: "alice", Text: "Partez maintenant"}
+	if err := sink.Notify(context.Background(), sent); err != nil {
+		t.Fatal(err)
+	}
+	if notification := <-received; notification.ReminderID != sent.ReminderID || notification.UserID != sent.UserID || notification.Text != sent.Text {
+		t.Errorf("expected %+v, got %+v", sent, notification)
+	}
+
+	status = http.StatusInternalServerError
+	if err := sink.Notify(context.Background(), sent); err == nil {
+		t.Errorf("expected the failure of the webhook to be reported")
+	}
+	<-received
+
+	// The notification is given up with the tick
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := sink.Notify(ctx, sent); err == nil {
+		t.Errorf("expected the notification to be given up")
+	}
+}
+
+// fakeSink records the notifications instead of sending them
+type fakeSink struct {
+	mu            sync.Mutex
+	notifications []Notification
+}
+
+func (s *fakeSink) Notify(ctx context.Context, notification Notification) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.notifications = append(s.notifications, notification)
+	return nil
+}
+
+// Notifications returns the notifications recorded so far
+func (s *fakeSink) Notifications() []Notification {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]Notification{}, s.notifications...)
+}
@@ -0,0 +1,94 @@
+package search
+
+import (
+	"sort"
+	"strings"
+)
+
+// maxAlternativeSearches bounds the number of searches made to find alternatives
+const maxAlternativeSearches = 64
+
+// FindAlternativePaths returns up to k distinct itineraries between two
+// stops, ranked by number of transfers and estimated duration. No
+// itinerary is returned when k is not positive.
+//
+// Alternatives are found by searching again while banning the lines used
+// by the itineraries already found, one more line at a time.
+func (s *BFS) FindAlternativePaths(source string, target string, k int) ([]Itinerary, error) {
+
+	start, end, err := s.endpoints(source, target)
+	if err != nil {
+		return []Itinerary{}, err
+	}
+
+	if k <= 0 {
+		return []Itinerary{}, nil
+	}
+
+	itineraries := []Itinerary{}
+	foundKeys := map[string]bool{}
+
+	bans := [][]string{{}}
+	triedBans := map[string]bool{"": true}
+
+	for searches := 0; len(bans) > 0 && searches < maxAlternativeSearches && len(itineraries) < 2*k; searches++ {
+		banned := bans[0]
+		bans = bans[1:]
+
+		state := newBFSState(len(s.graph), DefaultSearchOptions)
+		for _, lineID := range banned {
+			state.bannedLines[lineID] = true
+		}
+
+		itinerary, err := s.search(state, start, end)
+		if err == ErrNoPathFound {
+			continue
+		} else if err != nil {
+			return []Itinerary{}, err
+		}
+
+		if key := itineraryKey(itinerary); !foundKeys[key] {
+			foundKeys[key] = true
+			itineraries = append(itineraries, itinerary)
+		}
+
+		// Walking legs have no line to ban
+		for _, leg := range itinerary.Legs {
+			if leg.Walk {
+				continue
+			}
+			next := append(append([]string{}, banned...), leg.Line.ID)
+			sort.Strings(next)
+
+			if key := strings.Join(next, ","); !triedBans[key] {
+				triedBans[key] = true
+				bans = append(bans, next)
+			}
+		}
+	}
+
+	if len(itineraries) == 0 {
+		return []Itinerary{}, ErrNoPathFound
+	}
+
+	sort.SliceStable(itineraries, func(i, j int) bool {
+		if itineraries[i].Transfers() != itineraries[j].Transfers() {
+			return itineraries[i].Transfers() < itineraries[j].Transfers()
+		}
+		return itineraries[i].EstimatedDuration() < itineraries[j].EstimatedDuration()
+	})
+
+	if len(itineraries) > k {
+		itineraries = itineraries[:k]
+	}
+	return itineraries, nil
+}
+
+// itineraryKey identifies an itinerary by the routes it uses and where they are left
+func itineraryKey(itinerary Itinerary) string {
+	parts := make([]string, len(itinerary.Legs))
+	for i, leg := range itinerary.Legs {
+		parts[i] = leg.RouteID + ">" + leg.Alight.Name
+	}
+	return strings.Join(parts, "|")
+}
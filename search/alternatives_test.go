@@ -0,0 +1,139 @@
+package search
+
+import (
+	"testing"
+
+	"github.com/gophersch/tlgo"
+	"github.com/yageek/tl-ai/dataprovider"
+	"github.com/yageek/tl-ai/storage"
+)
+
+// alternativesStore goes from A to C with line 1 through B, line 2
+// through X and Y, or lines 3 and 4 changing at B.
+func alternativesStore() *storage.Store {
+
+	stops := func(names ...string) []tlgo.StopRouteDetails {
+		details := make([]tlgo.StopRouteDetails, len(names))
+		for i, name := range names {
+			details[i] = tlgo.StopRouteDetails{StopAreaName: name}
+		}
+		return details
+	}
+
+	return storage.NewStore(dataprovider.APIRawData{
+		Stops: []tlgo.Stop{
+			{ID: "a", Name: "A"},
+			{ID: "b", Name: "B"},
+			{ID: "c", Name: "C"},
+			{ID: "x", Name: "X"},
+			{ID: "y", Name: "Y"},
+		},
+		Lines: []tlgo.Line{
+			{ID: "L1", ShortName: "1"},
+			{ID: "L2", ShortName: "2"},
+			{ID: "L3", ShortName: "3"},
+			{ID: "L4", ShortName: "4"},
+		},
+		RoutesByLineID: map[string][]tlgo.Route{
+			"L1": {{ID: "r1", CityDestinationStopName: "C"}},
+			"L2": {{ID: "r2", CityDestinationStopName: "C"}},
+			"L3": {{ID: "r3", CityDestinationStopName: "B"}},
+			"L4": {{ID: "r4", CityDestinationStopName: "C"}},
+		},
+		RoutesDetailsByRouteID: map[string]tlgo.RouteDetails{
+			"r1": {LineID: "L1", Stops: stops("A", "B", "C")},
+			"r2": {LineID: "L2", Stops: stops("A", "X", "Y", "C")},
+			"r3": {LineID: "L3", Stops: stops("A", "B")},
+			"r4": {LineID: "L4", Stops: stops("B", "C")},
+		},
+	})
+}
+
+func TestFindAlternativePaths(t *testing.T) {
+
+	bfs, err := NewBFSWithOptions(*alternativesStore(), GraphOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	itineraries, err := bfs.FindAlternativePaths("A", "C", 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The direct lines come first, the shortest one leading
+	want := [][]string{{"1"}, {"2"}, {"3", "4"}}
+	if len(itineraries) != len(want) {
+		t.Fatalf("expected %d itineraries, got %d", len(want), len(itineraries))
+	}
+	for i, itinerary := range itineraries {
+		lines := []string{}
+		for _, leg := range itinerary.Legs {
+			lines = append(lines, leg.Line.ShortName)
+		}
+		if len(lines) != len(want[i]) {
+			t.Errorf("itinerary %d: expected lines %v, got %v", i, want[i], lines)
+			continue
+		}
+		for j := range lines {
+			if lines[j] != want[i][j] {
+				t.Errorf("itinerary %d: expected lines %v, got %v", i, want[i], lines)
+				break
+			}
+		}
+	}
+
+	itineraries, err = bfs.FindAlternativePaths("A", "C", 1)
+	if err != nil || len(itineraries) != 1 {
+		t.Errorf("expected a single itinerary, got %d (%v)", len(itineraries), err)
+	}
+
+	if _, err := bfs.FindAlternativePaths("C", "A", 3); err != ErrNoPathFound {
+		t.Errorf("expected %v, got %v", ErrNoPathFound, err)
+	}
+
+	for _, k := range []int{0, -1} {
+		itineraries, err = bfs.FindAlternativePaths("A", "C", k)
+		if err != nil || len(itineraries) != 0 {
+			t.Errorf("k=%d: expected no itinerary, got %d (%v)", k, len(itineraries), err)
+		}
+	}
+}
+
+func TestFindAlternativePathsWalking(t *testing.T) {
+
+	// Q and R are about 110 meters apart, lines 1 and 2 end at P and S
+	store := storage.NewStore(dataprovider.APIRawData{
+		Stops: []tlgo.Stop{
+			{ID: "p", Name: "P", Lat: 46.5150, Lng: 6.6300},
+			{ID: "q", Name: "Q", Lat: 46.5210, Lng: 6.6300},
+			{ID: "r", Name: "R", Lat: 46.5220, Lng: 6.6300},
+			{ID: "s", Name: "S", Lat: 46.5300, Lng: 6.6300},
+		},
+		Lines: []tlgo.Line{
+			{ID: "W1", ShortName: "w1"},
+			{ID: "W2", ShortName: "w2"},
+		},
+		RoutesByLineID: map[string][]tlgo.Route{
+			"W1": {{ID: "w1", CityDestinationStopName: "Q"}},
+			"W2": {{ID: "w2", CityDestinationStopName: "S"}},
+		},
+		RoutesDetailsByRouteID: map[string]tlgo.RouteDetails{
+			"w1": {LineID: "W1", Stops: []tlgo.StopRouteDetails{{StopAreaName: "P"}, {StopAreaName: "Q"}}},
+			"w2": {LineID: "W2", Stops: []tlgo.StopRouteDetails{{StopAreaName: "R"}, {StopAreaName: "S"}}},
+		},
+	})
+
+	bfs, err := NewBFS(*store)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	itineraries, err := bfs.FindAlternativePaths("P", "S", 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(itineraries) != 1 || len(itineraries[0].Legs) != 3 || !itineraries[0].Legs[1].Walk {
+		t.Errorf("expected a single itinerary walking from Q to R, got %+v", itineraries)
+	}
+}
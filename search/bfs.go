@@ -4,44 +4,143 @@ import (
 	"errors"
 	"fmt"
 	"log"
+	"time"
 
 	"github.com/gophersch/tlgo"
+	"github.com/yageek/tl-ai/geo"
 	"github.com/yageek/tl-ai/storage"
 )
 
+// linkKind tells how a link moves between two nodes of the graph
+type linkKind int
+
+const (
+	// rideLink goes to the next stop on board of the same route
+	rideLink linkKind = iota
+	// transferLink changes of route at the same stop area
+	transferLink
+	// boardLink enters a route at the starting stop of a search
+	boardLink
+	// alightLink leaves a route at the target stop of a search
+	alightLink
+	// walkLink goes on foot to a nearby stop area
+	walkLink
+)
+
 type bsfLink struct {
+	kind linkKind
+	node *bfsNode
+	// duration is the walking time of walk links
+	duration time.Duration
+}
+
+type bsfMove struct {
+	fromLabel int
+	viaLink   *bsfLink
+}
+
+// routePattern is a route travelled along its stops. The wayback of a
+// line is a route of its own, its stops being listed in travel order.
+type routePattern struct {
 	routeID string
+	route   tlgo.Route
 	details tlgo.RouteDetails
-	node    *bfsNode
 	line    tlgo.Line
 }
 
-type bsfMove struct {
-	fromNode *bfsNode
-	viaLink  *bsfLink
-}
+// bfsNode is either a stop area or the passage of a route at a stop area
 type bfsNode struct {
-	in      bsfMove
-	links   []*bsfLink
-	visited bool
-	stop    tlgo.Stop
+	index int
+	links []*bsfLink
+	stop  tlgo.Stop
+	// pattern is nil for the stop area nodes
+	pattern *routePattern
+}
+
+func (n *bfsNode) linkToNode(o *bfsNode, kind linkKind) {
+	n.walkToNode(o, kind, 0)
 }
 
-func (n *bfsNode) linkToNode(o *bfsNode, routeID string, line tlgo.Line, details tlgo.RouteDetails) {
+func (n *bfsNode) walkToNode(o *bfsNode, kind linkKind, duration time.Duration) {
 	link := &bsfLink{
-		routeID: routeID,
-		details: details,
-		node:    o,
-		line:    line,
+		kind:     kind,
+		node:     o,
+		duration: duration,
 	}
 	n.links = append(n.links, link)
 }
 
-func (n *bfsNode) mark() {
-	n.visited = true
+// GraphOptions tunes the building of the graph
+type GraphOptions struct {
+	// WalkingRadius is the maximum distance in meters walked between two
+	// stop areas. Walking is disabled when it is zero.
+	WalkingRadius float64
+	// WalkingSpeed is the walking speed in meters per second, as the crow flies
+	WalkingSpeed float64
+}
+
+// DefaultGraphOptions are the options used by NewBFS
+var DefaultGraphOptions = GraphOptions{
+	WalkingRadius: 400,
+	WalkingSpeed:  1.0,
 }
 
-// BFS represents a bread first search pass
+// SearchOptions tunes the itinerary search
+type SearchOptions struct {
+	// TransferPenalty is the cost of changing of route
+	TransferPenalty time.Duration
+	// MaxTransfers is the maximum number of changes of an itinerary
+	MaxTransfers int
+}
+
+// DefaultSearchOptions are the options used by FindStopToStopPath
+var DefaultSearchOptions = SearchOptions{
+	TransferPenalty: estimatedTransferDuration,
+	MaxTransfers:    4,
+}
+
+// bfsState holds the search state of a single query so the
+// graph itself is never modified while searching. A label is
+// a node reached after a given number of transfers.
+type bfsState struct {
+	options SearchOptions
+	in      []bsfMove
+	costs   []time.Duration
+	settled []bool
+	// bannedLines are the IDs of the lines the search must not use
+	bannedLines map[string]bool
+}
+
+func newBFSState(size int, options SearchOptions) *bfsState {
+	if options.MaxTransfers < 0 {
+		options.MaxTransfers = 0
+	}
+
+	labels := size * (options.MaxTransfers + 1)
+	state := &bfsState{
+		options:     options,
+		in:          make([]bsfMove, labels),
+		costs:       make([]time.Duration, labels),
+		settled:     make([]bool, labels),
+		bannedLines: map[string]bool{},
+	}
+	for i := range state.costs {
+		state.costs[i] = -1
+	}
+	return state
+}
+
+func (s *bfsState) label(n *bfsNode, transfers int) int {
+	return n.index*(s.options.MaxTransfers+1) + transfers
+}
+
+// BFS represents the transit graph searched for itineraries. It is
+// read only once built and can serve concurrent queries.
+//
+// Every route passing at a stop area has its own node, linked to the
+// next stop of the route by a ride link, to the other routes passing
+// at the same stop area by transfer links and to the routes passing at
+// nearby stop areas by walk links.
 type BFS struct {
 	graph           []*bfsNode
 	nodesByStopName map[string]*bfsNode
@@ -54,6 +153,11 @@ var (
 
 // NewBFS Create a new BFS session
 func NewBFS(store storage.Store) (*BFS, error) {
+	return NewBFSWithOptions(store, DefaultGraphOptions)
+}
+
+// NewBFSWithOptions Create a new BFS session with the provided options
+func NewBFSWithOptions(store storage.Store, options GraphOptions) (*BFS, error) {
 
 	stops, err := store.GetStops()
 	if err != nil {
@@ -65,114 +169,271 @@ func NewBFS(store storage.Store) (*BFS, error) {
 		return nil, err
 	}
 
-	stopsNode := make([]*bfsNode, len(stops))
+	graph := make([]*bfsNode, len(stops))
 	nameIndex := make(map[string]*bfsNode, len(stops))
 
 	for k := range stops {
 
 		// Create the node of the stops
 		node := &bfsNode{
-			stop:    stops[k],
-			visited: false,
+			index: k,
+			stop:  stops[k],
 		}
-		stopsNode[k] = node
+		graph[k] = node
 		nameIndex[stops[k].Name] = node
 	}
 
+	// Route nodes passing at each stop area
+	routeNodesByStop := make(map[*bfsNode][]*bfsNode, len(stops))
+
 	for routeID, details := range routesDetails {
-		var previous *bfsNode
 
+		line, err := store.GetLineForRouteID(routeID)
+		if err != nil {
+			log.Printf("Line not found for route ID: %s\n", err)
+			continue
+		}
+
+		route, err := store.GetRouteByID(routeID)
+		if err != nil {
+			log.Printf("Route not found for route ID: %s\n", err)
+			continue
+		}
+
+		stopNodes := []*bfsNode{}
 		for _, stopDetails := range details.Stops {
-			current, hasFound := nameIndex[stopDetails.StopAreaName]
-			if !hasFound {
-				continue
+			if current, hasFound := nameIndex[stopDetails.StopAreaName]; hasFound {
+				stopNodes = append(stopNodes, current)
 			}
+		}
 
-			line, err := store.GetLineForRouteID(routeID)
-			if err != nil {
-				fmt.Printf("Line not found for route ID: %s\n", err)
-				continue
+		pattern := &routePattern{routeID: routeID, route: route, details: details, line: line}
+
+		var previous *bfsNode
+		for _, stopNode := range stopNodes {
+
+			current := &bfsNode{
+				index:   len(graph),
+				stop:    stopNode.stop,
+				pattern: pattern,
 			}
+			graph = append(graph, current)
+
+			stopNode.linkToNode(current, boardLink)
+			current.linkToNode(stopNode, alightLink)
+			routeNodesByStop[stopNode] = append(routeNodesByStop[stopNode], current)
 
 			if previous != nil {
-				previous.linkToNode(current, routeID, line, details)
+				previous.linkToNode(current, rideLink)
+			}
+			previous = current
+		}
+	}
 
-				if details.Wayback {
-					current.linkToNode(previous, routeID, line, details)
+	// Transfers between the routes passing at the same stop area
+	for _, routeNodes := range routeNodesByStop {
+		for _, from := range routeNodes {
+			for _, to := range routeNodes {
+				if from.pattern != to.pattern {
+					from.linkToNode(to, transferLink)
 				}
 			}
-			previous = current
 		}
 	}
 
+	if options.WalkingRadius > 0 && options.WalkingSpeed > 0 {
+		linkWalkingTransfers(graph[:len(stops)], routeNodesByStop, options)
+	}
+
 	return &BFS{
-		graph:           stopsNode,
+		graph:           graph,
 		nodesByStopName: nameIndex,
 	}, nil
 }
 
-// Step represents a bus stop travel step
-type Step struct {
-	Stop         tlgo.Stop
-	RouteDetails tlgo.RouteDetails
-	RouteID      string
-	Line         tlgo.Line
+// linkWalkingTransfers links the stop areas close to each other with walk links
+func linkWalkingTransfers(stopNodes []*bfsNode, routeNodesByStop map[*bfsNode][]*bfsNode, options GraphOptions) {
+
+	points := make([]geo.Point, len(stopNodes))
+	for i, node := range stopNodes {
+		points[i] = geo.Point{Lat: node.stop.Lat, Lng: node.stop.Lng}
+	}
+	grid := geo.NewGrid(points, options.WalkingRadius)
+
+	for i, from := range stopNodes {
+		if points[i].IsZero() {
+			continue
+		}
+
+		for _, neighbour := range grid.Within(points[i], options.WalkingRadius) {
+			to := stopNodes[neighbour.Index]
+			if to == from || to.stop.Name == from.stop.Name {
+				continue
+			}
+
+			duration := time.Duration(neighbour.Distance/options.WalkingSpeed) * time.Second
+
+			// Walk from the start, between two routes or to the target
+			for _, toRoute := range routeNodesByStop[to] {
+				from.walkToNode(toRoute, walkLink, duration)
+			}
+			for _, fromRoute := range routeNodesByStop[from] {
+				for _, toRoute := range routeNodesByStop[to] {
+					fromRoute.walkToNode(toRoute, walkLink, duration)
+				}
+				fromRoute.walkToNode(to, walkLink, duration)
+			}
+		}
+	}
 }
 
-// FindStopToStopPath finds the path between two stops if it exists
-func (s *BFS) FindStopToStopPath(source string, target string) ([]Step, error) {
+// FindStopToStopPath finds the itinerary between two stops if it exists
+func (s *BFS) FindStopToStopPath(source string, target string) (Itinerary, error) {
+	return s.FindStopToStopPathWithOptions(source, target, DefaultSearchOptions)
+}
 
-	log.Printf("Starting search from %s -> %s\n", source, target)
+// FindStopToStopPathWithOptions finds the itinerary between two stops
+// with the least cost according to the options
+func (s *BFS) FindStopToStopPathWithOptions(source string, target string, options SearchOptions) (Itinerary, error) {
+
+	start, end, err := s.endpoints(source, target)
+	if err != nil {
+		return Itinerary{}, err
+	}
+	return s.search(newBFSState(len(s.graph), options), start, end)
+}
+
+func (s *BFS) endpoints(source string, target string) (*bfsNode, *bfsNode, error) {
 
 	start, hasStart := s.nodesByStopName[source]
 	if !hasStart {
-		return []Step{}, fmt.Errorf("Starting stop %s was not found", source)
+		return nil, nil, fmt.Errorf("Starting stop %s was not found", source)
 	}
 	end, hastarget := s.nodesByStopName[target]
 	if !hastarget {
-		return []Step{}, fmt.Errorf("Target stop %s was not found", target)
+		return nil, nil, fmt.Errorf("Target stop %s was not found", target)
+	}
+	return start, end, nil
+}
 
+// cost returns the cost of following a link and whether it is a transfer
+func (s *bfsState) cost(from *bfsNode, link *bsfLink) (time.Duration, bool) {
+	switch link.kind {
+	case rideLink:
+		return estimatedHopDuration, false
+	case transferLink:
+		return s.options.TransferPenalty, true
+	case walkLink:
+		// Walking between two routes is a change, walking at the ends is not
+		if from.pattern != nil && link.node.pattern != nil {
+			return link.duration + s.options.TransferPenalty, true
+		}
+		return link.duration, false
+	default:
+		return 0, false
 	}
-	return bfsSearchStopToStop(start, end)
 }
 
-func bfsSearchStopToStop(start *bfsNode, target *bfsNode) ([]Step, error) {
-	queue := newQueue(1)
+// search runs a Dijkstra search over the labels of the graph
+func (s *BFS) search(state *bfsState, start *bfsNode, target *bfsNode) (Itinerary, error) {
+
+	queue := &labelQueue{}
+
+	first := state.label(start, 0)
+	state.costs[first] = 0
+	state.in[first] = bsfMove{fromLabel: -1}
+	queue.push(first, 0)
+
+	for queue.Len() > 0 {
+		current := queue.pop()
+		if state.settled[current] {
+			continue
+		}
+		state.settled[current] = true
 
-	queue.push(start)
-	start.mark()
-	for queue.count != 0 {
-		n := queue.pop()
+		maxTransfers := state.options.MaxTransfers + 1
+		n, transfers := s.graph[current/maxTransfers], current%maxTransfers
 
 		if n == target {
+			return s.itinerary(state, start, target, current), nil
+		}
 
-			path := []Step{}
-			nodeCursor := n
+		for _, link := range n.links {
 
-			for nodeCursor.in.fromNode != nil {
+			// Stop areas are only entered to end the search and only left to start it
+			if link.node.pattern == nil && link.node != target {
+				continue
+			}
+			if n.pattern == nil && n != start {
+				continue
+			}
+			if link.node.pattern != nil && state.bannedLines[link.node.pattern.line.ID] {
+				continue
+			}
 
-				step := Step{
-					RouteID:      nodeCursor.in.viaLink.routeID,
-					RouteDetails: nodeCursor.in.viaLink.details,
-					Line:         nodeCursor.in.viaLink.line,
-					Stop:         nodeCursor.stop,
-				}
+			cost, isTransfer := state.cost(n, link)
+			nextTransfers := transfers
+			if isTransfer {
+				nextTransfers++
+			}
+			if nextTransfers > state.options.MaxTransfers {
+				continue
+			}
 
-				path = append(path, step)
-				fmt.Printf("Stop: %s | Step Route: %s | Step Line: %s\n", step.Stop.Name, step.RouteID, step.Line)
-				nodeCursor = nodeCursor.in.fromNode
+			next := state.label(link.node, nextTransfers)
+			nextCost := state.costs[current] + cost
+			if state.settled[next] || (state.costs[next] >= 0 && state.costs[next] <= nextCost) {
+				continue
 			}
 
-			return path, nil
+			state.costs[next] = nextCost
+			state.in[next] = bsfMove{fromLabel: current, viaLink: link}
+			queue.push(next, nextCost)
 		}
+	}
+	return Itinerary{}, ErrNoPathFound
+}
 
-		for _, c := range n.links {
-			if !c.node.visited {
-				c.node.in = bsfMove{n, c}
-				queue.push(c.node)
-				c.node.mark()
-			}
+// itinerary walks back the predecessors of the target label and
+// returns the ride hops in travel order
+func (s *BFS) itinerary(state *bfsState, start *bfsNode, target *bfsNode, targetLabel int) Itinerary {
+
+	maxTransfers := state.options.MaxTransfers + 1
+	hops := []hop{}
+
+	for cursor := targetLabel; state.in[cursor].fromLabel >= 0; cursor = state.in[cursor].fromLabel {
+
+		in := state.in[cursor]
+		from := s.graph[in.fromLabel/maxTransfers]
+		to := s.graph[cursor/maxTransfers]
+
+		if in.viaLink.kind == walkLink {
+			hops = append(hops, hop{
+				from:     from.stop,
+				to:       to.stop,
+				walk:     true,
+				duration: in.viaLink.duration,
+			})
+			continue
 		}
+
+		if in.viaLink.kind != rideLink {
+			continue
+		}
+
+		hops = append(hops, hop{
+			from:    from.stop,
+			to:      to.stop,
+			routeID: to.pattern.routeID,
+			route:   to.pattern.route,
+			details: to.pattern.details,
+			line:    to.pattern.line,
+		})
 	}
-	return nil, ErrNoPathFound
+
+	for i, j := 0, len(hops)-1; i < j; i, j = i+1, j-1 {
+		hops[i], hops[j] = hops[j], hops[i]
+	}
+
+	return newItinerary(start.stop, target.stop, hops)
 }
@@ -0,0 +1,228 @@
+package search
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/gophersch/tlgo"
+	"github.com/yageek/tl-ai/dataprovider"
+	"github.com/yageek/tl-ai/storage"
+)
+
+// testStore is a small network: line 1 goes from A to C and back on its
+// wayback route, line 2 goes from B to D and line 3 only has a wayback
+// route from E to F.
+func testStore() *storage.Store {
+	return storage.NewStore(dataprovider.APIRawData{
+		Stops: []tlgo.Stop{
+			{ID: "a", Name: "A"},
+			{ID: "b", Name: "B"},
+			{ID: "c", Name: "C"},
+			{ID: "d", Name: "D"},
+			{ID: "e", Name: "E"},
+			{ID: "f", Name: "F"},
+		},
+		Lines: []tlgo.Line{
+			{ID: "L1", ShortName: "1"},
+			{ID: "L2", ShortName: "2"},
+			{ID: "L3", ShortName: "3"},
+		},
+		RoutesByLineID: map[string][]tlgo.Route{
+			"L1": {
+				{ID: "r1", CityDestinationStopName: "C"},
+				{ID: "r1b", CityDestinationStopName: "A", Wayback: true},
+			},
+			"L2": {{ID: "r2", CityDestinationStopName: "D"}},
+			"L3": {{ID: "r3b", CityDestinationStopName: "F", Wayback: true}},
+		},
+		RoutesDetailsByRouteID: map[string]tlgo.RouteDetails{
+			"r1":  {LineID: "L1", Stops: []tlgo.StopRouteDetails{{StopAreaName: "A"}, {StopAreaName: "B"}, {StopAreaName: "C"}}},
+			"r1b": {LineID: "L1", Wayback: true, Stops: []tlgo.StopRouteDetails{{StopAreaName: "C"}, {StopAreaName: "B"}, {StopAreaName: "A"}}},
+			"r2":  {LineID: "L2", Stops: []tlgo.StopRouteDetails{{StopAreaName: "B"}, {StopAreaName: "D"}}},
+			"r3b": {LineID: "L3", Wayback: true, Stops: []tlgo.StopRouteDetails{{StopAreaName: "E"}, {StopAreaName: "F"}}},
+		},
+	})
+}
+
+func newTestBFS(t *testing.T) *BFS {
+	t.Helper()
+	bfs, err := NewBFSWithOptions(*testStore(), GraphOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return bfs
+}
+
+func TestFindStopToStopPath(t *testing.T) {
+
+	bfs := newTestBFS(t)
+
+	itinerary, err := bfs.FindStopToStopPath("A", "D")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if itinerary.Origin.Name != "A" || itinerary.Destination.Name != "D" {
+		t.Errorf("expected an itinerary from A to D, got %s to %s", itinerary.Origin.Name, itinerary.Destination.Name)
+	}
+
+	want := []struct{ board, alight, line string }{{"A", "B", "1"}, {"B", "D", "2"}}
+	if len(itinerary.Legs) != len(want) {
+		t.Fatalf("expected %d legs, got %d", len(want), len(itinerary.Legs))
+	}
+	for i, leg := range itinerary.Legs {
+		if leg.Board.Name != want[i].board || leg.Alight.Name != want[i].alight || leg.Line.ShortName != want[i].line {
+			t.Errorf("leg %d: expected line %s from %s to %s, got line %s from %s to %s", i, want[i].line, want[i].board, want[i].alight, leg.Line.ShortName, leg.Board.Name, leg.Alight.Name)
+		}
+	}
+
+	itinerary, err = bfs.FindStopToStopPath("A", "C")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(itinerary.Legs) != 1 || len(itinerary.Legs[0].Intermediate) != 1 || itinerary.Legs[0].Intermediate[0].Name != "B" {
+		t.Errorf("expected a single leg through B, got %+v", itinerary.Legs)
+	}
+
+	if _, err := bfs.FindStopToStopPath("D", "A"); err != ErrNoPathFound {
+		t.Errorf("expected no path from D to A, got %v", err)
+	}
+}
+
+func TestFindStopToStopPathConcurrent(t *testing.T) {
+
+	bfs := newTestBFS(t)
+
+	// Run with -race, the searches share the graph
+	done := make(chan error)
+	for i := 0; i < 50; i++ {
+		go func() {
+			itinerary, err := bfs.FindStopToStopPath("A", "D")
+			if err == nil && (len(itinerary.Legs) != 2 || itinerary.Legs[1].Alight.Name != "D") {
+				err = fmt.Errorf("unexpected itinerary %+v", itinerary)
+			}
+			done <- err
+		}()
+		go func() {
+			_, err := bfs.FindStopToStopPath("A", "C")
+			done <- err
+		}()
+	}
+
+	for i := 0; i < 100; i++ {
+		if err := <-done; err != nil {
+			t.Error(err)
+		}
+	}
+}
+
+func TestFindStopToStopPathTransferCosts(t *testing.T) {
+
+	bfs, err := NewBFSWithOptions(*alternativesStore(), GraphOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Without line 1, line 2 is longer than changing at B
+	withoutLine1 := func(options SearchOptions) (Itinerary, error) {
+		state := newBFSState(len(bfs.graph), options)
+		state.bannedLines["L1"] = true
+		start, end, err := bfs.endpoints("A", "C")
+		if err != nil {
+			t.Fatal(err)
+		}
+		return bfs.search(state, start, end)
+	}
+
+	tests := []struct {
+		name    string
+		options SearchOptions
+		legs    int
+	}{
+		{"default penalty", DefaultSearchOptions, 1},
+		{"free transfers", SearchOptions{TransferPenalty: 0, MaxTransfers: 4}, 2},
+		{"no transfer", SearchOptions{TransferPenalty: 0, MaxTransfers: 0}, 1},
+	}
+
+	for _, test := range tests {
+		itinerary, err := withoutLine1(test.options)
+		if err != nil {
+			t.Errorf("%s: %v", test.name, err)
+			continue
+		}
+		if len(itinerary.Legs) != test.legs {
+			t.Errorf("%s: expected %d legs, got %d", test.name, test.legs, len(itinerary.Legs))
+		}
+	}
+
+	// A to D needs a change at B
+	if _, err := newTestBFS(t).FindStopToStopPathWithOptions("A", "D", SearchOptions{MaxTransfers: 0}); err != ErrNoPathFound {
+		t.Errorf("expected %v without transfer, got %v", ErrNoPathFound, err)
+	}
+}
+
+func TestFindStopToStopPathWalking(t *testing.T) {
+
+	// Q and R are about 110 meters apart, the other stops are too far to walk
+	store := storage.NewStore(dataprovider.APIRawData{
+		Stops: []tlgo.Stop{
+			{ID: "p", Name: "P", Lat: 46.5150, Lng: 6.6300},
+			{ID: "q", Name: "Q", Lat: 46.5210, Lng: 6.6300},
+			{ID: "r", Name: "R", Lat: 46.5220, Lng: 6.6300},
+			{ID: "s", Name: "S", Lat: 46.5300, Lng: 6.6300},
+		},
+		Lines: []tlgo.Line{
+			{ID: "W1", ShortName: "w1"},
+			{ID: "W2", ShortName: "w2"},
+		},
+		RoutesByLineID: map[string][]tlgo.Route{
+			"W1": {{ID: "w1", CityDestinationStopName: "Q"}},
+			"W2": {{ID: "w2", CityDestinationStopName: "S"}},
+		},
+		RoutesDetailsByRouteID: map[string]tlgo.RouteDetails{
+			"w1": {LineID: "W1", Stops: []tlgo.StopRouteDetails{{StopAreaName: "P"}, {StopAreaName: "Q"}}},
+			"w2": {LineID: "W2", Stops: []tlgo.StopRouteDetails{{StopAreaName: "R"}, {StopAreaName: "S"}}},
+		},
+	})
+
+	withoutWalking, err := NewBFSWithOptions(*store, GraphOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := withoutWalking.FindStopToStopPath("P", "S"); err != ErrNoPathFound {
+		t.Errorf("expected %v without walking, got %v", ErrNoPathFound, err)
+	}
+
+	bfs, err := NewBFS(*store)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	itinerary, err := bfs.FindStopToStopPath("P", "S")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(itinerary.Legs) != 3 {
+		t.Fatalf("expected three legs, got %+v", itinerary.Legs)
+	}
+
+	walk := itinerary.Legs[1]
+	if !walk.Walk || walk.Board.Name != "Q" || walk.Alight.Name != "R" {
+		t.Fatalf("expected to walk from Q to R, got %+v", walk)
+	}
+	if walk.WalkDuration < 100*time.Second || walk.WalkDuration > 120*time.Second {
+		t.Errorf("expected to walk about 110 seconds, got %s", walk.WalkDuration)
+	}
+	if itinerary.Transfers() != 1 {
+		t.Errorf("expected a single change, got %d", itinerary.Transfers())
+	}
+
+	// Walking to the first stop is not a change
+	itinerary, err = bfs.FindStopToStopPath("Q", "S")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(itinerary.Legs) != 2 || !itinerary.Legs[0].Walk || itinerary.Transfers() != 0 {
+		t.Errorf("expected to walk to R and ride to S, got %+v", itinerary.Legs)
+	}
+}
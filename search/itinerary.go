@@ -0,0 +1,156 @@
+package search
+
+import (
+	"time"
+
+	"github.com/gophersch/tlgo"
+)
+
+// Leg is a part of an itinerary made on board of the same route or on foot
+type Leg struct {
+	// Walk tells if the leg is made on foot. Only the stops and
+	// WalkDuration are set for walking legs.
+	Walk         bool
+	WalkDuration time.Duration
+	// Board is the stop where the leg starts
+	Board tlgo.Stop
+	// Alight is the stop where the leg ends
+	Alight tlgo.Stop
+	// Intermediate are the stops served between Board and Alight
+	Intermediate []tlgo.Stop
+	Line         tlgo.Line
+	RouteID      string
+	RouteDetails tlgo.RouteDetails
+	// Direction is the destination displayed on the vehicle
+	Direction string
+	// Departure and Arrival are the scheduled times of the leg. They are
+	// only known for the itineraries returned by a Router.
+	Departure time.Time
+	Arrival   time.Time
+}
+
+// Itinerary is the ordered list of legs going from Origin to Destination
+type Itinerary struct {
+	Origin      tlgo.Stop
+	Destination tlgo.Stop
+	Legs        []Leg
+}
+
+// Transfers returns the number of changes of the itinerary
+func (i Itinerary) Transfers() int {
+	return len(i.TransferStops())
+}
+
+const (
+	// estimatedHopDuration is the average time between two consecutive stops
+	estimatedHopDuration = 90 * time.Second
+	// estimatedTransferDuration is the average time needed to change of vehicle
+	estimatedTransferDuration = 4 * time.Minute
+)
+
+// TransferStops returns the stops where the itinerary changes of route
+func (i Itinerary) TransferStops() []tlgo.Stop {
+	stops := []tlgo.Stop{}
+	hasRidden := false
+	for _, leg := range i.Legs {
+		if leg.Walk {
+			continue
+		}
+		if hasRidden {
+			stops = append(stops, leg.Board)
+		}
+		hasRidden = true
+	}
+	return stops
+}
+
+// EstimatedDuration returns the duration of the itinerary. The scheduled
+// times are used when known, otherwise it is estimated from the number
+// of stops and transfers.
+func (i Itinerary) EstimatedDuration() time.Duration {
+	if len(i.Legs) == 0 {
+		return 0
+	}
+
+	first, last := i.Legs[0], i.Legs[len(i.Legs)-1]
+	if !first.Departure.IsZero() && !last.Arrival.IsZero() {
+		return last.Arrival.Sub(first.Departure)
+	}
+
+	duration := time.Duration(i.Transfers()) * estimatedTransferDuration
+	for _, leg := range i.Legs {
+		if leg.Walk {
+			duration += leg.WalkDuration
+			continue
+		}
+		duration += time.Duration(len(leg.Intermediate)+1) * estimatedHopDuration
+	}
+	return duration
+}
+
+// hop is a move between two consecutive stops of a route
+type hop struct {
+	from      tlgo.Stop
+	to        tlgo.Stop
+	routeID   string
+	route     tlgo.Route
+	details   tlgo.RouteDetails
+	line      tlgo.Line
+	departure time.Time
+	arrival   time.Time
+	// walk hops only have their stops and duration set
+	walk     bool
+	duration time.Duration
+}
+
+// direction returns the destination of the vehicle making the hop
+func (h hop) direction() string {
+	return h.route.CityDestinationStopName
+}
+
+// newItinerary groups consecutive hops made on the same route into legs.
+// The hops must be provided in travel order.
+func newItinerary(origin, destination tlgo.Stop, hops []hop) Itinerary {
+
+	itinerary := Itinerary{
+		Origin:      origin,
+		Destination: destination,
+		Legs:        []Leg{},
+	}
+
+	for _, h := range hops {
+
+		if h.walk {
+			itinerary.Legs = append(itinerary.Legs, Leg{
+				Walk:         true,
+				WalkDuration: h.duration,
+				Board:        h.from,
+				Alight:       h.to,
+				Intermediate: []tlgo.Stop{},
+			})
+			continue
+		}
+
+		if last := len(itinerary.Legs) - 1; last >= 0 && !itinerary.Legs[last].Walk && itinerary.Legs[last].RouteID == h.routeID && itinerary.Legs[last].Alight.Name == h.from.Name {
+			leg := &itinerary.Legs[last]
+			leg.Intermediate = append(leg.Intermediate, leg.Alight)
+			leg.Alight = h.to
+			leg.Arrival = h.arrival
+			continue
+		}
+
+		itinerary.Legs = append(itinerary.Legs, Leg{
+			Board:        h.from,
+			Alight:       h.to,
+			Intermediate: []tlgo.Stop{},
+			Line:         h.line,
+			RouteID:      h.routeID,
+			RouteDetails: h.details,
+			Direction:    h.direction(),
+			Departure:    h.departure,
+			Arrival:      h.arrival,
+		})
+	}
+
+	return itinerary
+}
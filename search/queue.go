@@ -1,44 +1,45 @@
 package search
 
-// queue is a basic FIFO queue based on a circular list that resizes as needed.
-type queue struct {
-	nodes []*bfsNode
-	size  int
-	head  int
-	tail  int
-	count int
+import (
+	"container/heap"
+	"time"
+)
+
+type queuedLabel struct {
+	label int
+	cost  time.Duration
+}
+
+// labelHeap implements heap.Interface ordering labels by cost.
+type labelHeap []queuedLabel
+
+func (h labelHeap) Len() int            { return len(h) }
+func (h labelHeap) Less(i, j int) bool  { return h[i].cost < h[j].cost }
+func (h labelHeap) Swap(i, j int)       { h[i], h[j] = h[j], h[i] }
+func (h *labelHeap) Push(x interface{}) { *h = append(*h, x.(queuedLabel)) }
+func (h *labelHeap) Pop() interface{} {
+	old := *h
+	item := old[len(old)-1]
+	*h = old[:len(old)-1]
+	return item
+}
+
+// labelQueue is a priority queue returning the cheapest label first.
+type labelQueue struct {
+	items labelHeap
 }
 
-// Newqueue returns a new queue with the given initial size.
-func newQueue(size int) *queue {
-	return &queue{
-		nodes: make([]*bfsNode, size),
-		size:  size,
-	}
+// Len returns the number of labels in the queue.
+func (q *labelQueue) Len() int {
+	return len(q.items)
 }
 
-// Push adds a node to the queue.
-func (q *queue) push(n *bfsNode) {
-	if q.head == q.tail && q.count > 0 {
-		nodes := make([]*bfsNode, len(q.nodes)+q.size)
-		copy(nodes, q.nodes[q.head:])
-		copy(nodes[len(q.nodes)-q.head:], q.nodes[:q.head])
-		q.head = 0
-		q.tail = len(q.nodes)
-		q.nodes = nodes
-	}
-	q.nodes[q.tail] = n
-	q.tail = (q.tail + 1) % len(q.nodes)
-	q.count++
+// push adds a label to the queue.
+func (q *labelQueue) push(label int, cost time.Duration) {
+	heap.Push(&q.items, queuedLabel{label: label, cost: cost})
 }
 
-// Pop removes and returns a node from the queue in first to last order.
-func (q *queue) pop() *bfsNode {
-	if q.count == 0 {
-		return nil
-	}
-	node := q.nodes[q.head]
-	q.head = (q.head + 1) % len(q.nodes)
-	q.count--
-	return node
+// pop removes and returns the cheapest label of the queue.
+func (q *labelQueue) pop() int {
+	return heap.Pop(&q.items).(queuedLabel).label
 }
@@ -0,0 +1,340 @@
+package search
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"sort"
+	"time"
+
+	"github.com/gophersch/tlgo"
+	"github.com/yageek/tl-ai/dataprovider"
+	"github.com/yageek/tl-ai/storage"
+)
+
+var (
+	// ErrNoTimetable is returned when the store does not hold any trip
+	ErrNoTimetable = errors.New("No timetable available")
+)
+
+// RouterOptions tunes the earliest arrival search
+type RouterOptions struct {
+	// TransferPenalty is the minimum time needed to change of vehicle. It
+	// is also added for each change when comparing itineraries.
+	TransferPenalty time.Duration
+	// MaxTransfers is the maximum number of changes of an itinerary
+	MaxTransfers int
+}
+
+// DefaultRouterOptions are the options used by NewRouter
+var DefaultRouterOptions = RouterOptions{
+	TransferPenalty: 3 * time.Minute,
+	MaxTransfers:    3,
+}
+
+// scannedDays is the number of service days scanned from the day of the
+// departure, the trips of the next day following the last ones of the day.
+const scannedDays = 2
+
+// connection is a vehicle going from a stop to the next one without stopping
+type connection struct {
+	trip      int
+	from      int
+	to        int
+	departure time.Duration
+	arrival   time.Duration
+}
+
+// before tells if the connection is scanned before the other one. The
+// connections arriving first come first so that a change to a vehicle
+// leaving at the same time is not missed.
+func (c connection) before(other connection) bool {
+	if c.departure != other.departure {
+		return c.departure < other.departure
+	}
+	return c.arrival < other.arrival
+}
+
+// tripInfo holds the route a trip runs along and the days it runs on
+type tripInfo struct {
+	routeID string
+	route   tlgo.Route
+	details tlgo.RouteDetails
+	line    tlgo.Line
+	day     dataprovider.ServiceDay
+}
+
+// Router computes earliest arrival itineraries using the
+// Connection Scan Algorithm on the timetable of the store.
+type Router struct {
+	options     RouterOptions
+	connections []connection
+	trips       []tripInfo
+	stops       []tlgo.Stop
+	stopIndex   map[string]int
+}
+
+// NewRouter builds a router from the timetable of the store
+func NewRouter(store storage.Store, options RouterOptions) (*Router, error) {
+
+	stops, err := store.GetStops()
+	if err != nil {
+		return nil, err
+	}
+
+	routesDetails, err := store.GetRoutesDetailsByRouteID()
+	if err != nil {
+		return nil, err
+	}
+
+	r := &Router{
+		options:   options,
+		stops:     make([]tlgo.Stop, 0, len(stops)),
+		stopIndex: make(map[string]int, len(stops)),
+	}
+
+	for _, stop := range stops {
+		r.indexOf(stop)
+	}
+
+	for routeID, details := range routesDetails {
+
+		trips, err := store.GetTripsForRouteID(routeID)
+		if err != nil {
+			continue
+		}
+
+		line, err := store.GetLineForRouteID(routeID)
+		if err != nil {
+			log.Printf("Line not found for route ID %s: %s\n", routeID, err)
+			continue
+		}
+
+		route, err := store.GetRouteByID(routeID)
+		if err != nil {
+			log.Printf("Route not found for route ID %s: %s\n", routeID, err)
+			continue
+		}
+
+		for _, trip := range trips {
+			tripID := len(r.trips)
+			r.trips = append(r.trips, tripInfo{routeID: routeID, route: route, details: details, line: line, day: trip.Day})
+
+			for i := 1; i < len(trip.StopTimes); i++ {
+				from, to := trip.StopTimes[i-1], trip.StopTimes[i]
+				r.connections = append(r.connections, connection{
+					trip:      tripID,
+					from:      r.indexOf(tlgo.Stop{Name: from.StopAreaName}),
+					to:        r.indexOf(tlgo.Stop{Name: to.StopAreaName}),
+					departure: from.Time,
+					arrival:   to.Time,
+				})
+			}
+		}
+	}
+
+	if len(r.connections) == 0 {
+		return nil, ErrNoTimetable
+	}
+
+	sort.SliceStable(r.connections, func(i, j int) bool {
+		return r.connections[i].before(r.connections[j])
+	})
+
+	return r, nil
+}
+
+func (r *Router) indexOf(stop tlgo.Stop) int {
+	index, hasIndex := r.stopIndex[stop.Name]
+	if !hasIndex {
+		index = len(r.stops)
+		r.stops = append(r.stops, stop)
+		r.stopIndex[stop.Name] = index
+	}
+	return index
+}
+
+// scanned returns the connection of a scan index, which is the index of the
+// connection on its service day: day*len(connections)+connection. Its trip
+// and times are shifted to tell the runs of the different days apart.
+func (r *Router) scanned(index int) connection {
+	day := index / len(r.connections)
+	conn := r.connections[index%len(r.connections)]
+	conn.trip += day * len(r.trips)
+	conn.departure += time.Duration(day) * 24 * time.Hour
+	conn.arrival += time.Duration(day) * 24 * time.Hour
+	return conn
+}
+
+// scanOrder returns the scan indexes of the connections leaving after the
+// offset on the day of the departure, followed by the ones of the next day,
+// in the order they are scanned.
+func (r *Router) scanOrder(offset time.Duration) []int {
+
+	n := len(r.connections)
+	today := sort.Search(n, func(i int) bool {
+		return r.connections[i].departure >= offset
+	})
+	tomorrow := n
+
+	// Trips running after midnight may leave after the first ones of the next day
+	order := make([]int, 0, 2*n-today)
+	for today < n || tomorrow < 2*n {
+		if tomorrow == 2*n || (today < n && !r.scanned(tomorrow).before(r.scanned(today))) {
+			order = append(order, today)
+			today++
+		} else {
+			order = append(order, tomorrow)
+			tomorrow++
+		}
+	}
+	return order
+}
+
+// csaLeg is the part of a trip used to reach a stop
+type csaLeg struct {
+	enter int
+	exit  int
+	// level is the number of transfers made before boarding
+	level int
+}
+
+// FindEarliestArrivalPath finds the itinerary leaving the source after
+// departure and reaching the target as early as possible, on board of the
+// trips running on the day of the departure or on the next one.
+// ErrNoPathFound is returned when the target can not be reached by then.
+func (r *Router) FindEarliestArrivalPath(source string, target string, departure time.Time) (Itinerary, error) {
+
+	start, hasStart := r.stopIndex[source]
+	if !hasStart {
+		return Itinerary{}, fmt.Errorf("Starting stop %s was not found", source)
+	}
+	end, hasTarget := r.stopIndex[target]
+	if !hasTarget {
+		return Itinerary{}, fmt.Errorf("Target stop %s was not found", target)
+	}
+
+	dayStart := time.Date(departure.Year(), departure.Month(), departure.Day(), 0, 0, 0, 0, departure.Location())
+	offset := dataprovider.ClockOffset(departure)
+
+	// The runs of the trips are told apart by day as the connections are scanned
+	days := make([]time.Time, scannedDays)
+	runs := make([]bool, scannedDays*len(r.trips))
+	for d := range days {
+		days[d] = dayStart.AddDate(0, 0, d)
+		for i, trip := range r.trips {
+			runs[d*len(r.trips)+i] = trip.day.Includes(days[d])
+		}
+	}
+
+	// arrivals[k][stop] is the earliest arrival at stop with at most k transfers
+	levels := r.options.MaxTransfers + 1
+	const never = time.Duration(1<<63 - 1)
+	arrivals := make([][]time.Duration, levels)
+	legs := make([][]csaLeg, levels)
+	boarded := make([][]int, levels)
+	for k := 0; k < levels; k++ {
+		arrivals[k] = make([]time.Duration, len(r.stops))
+		legs[k] = make([]csaLeg, len(r.stops))
+		for i := range arrivals[k] {
+			arrivals[k][i] = never
+		}
+		boarded[k] = make([]int, len(runs))
+		for i := range boarded[k] {
+			boarded[k][i] = -1
+		}
+	}
+
+	for _, index := range r.scanOrder(offset) {
+		conn := r.scanned(index)
+
+		// The connections leaving later can not arrive earlier
+		if conn.departure >= arrivals[levels-1][end] {
+			break
+		}
+		if !runs[conn.trip] {
+			continue
+		}
+
+		for k := 0; k < levels; k++ {
+
+			if boarded[k][conn.trip] < 0 {
+				canBoard := conn.from == start
+				if !canBoard && k > 0 && arrivals[k-1][conn.from] != never {
+					canBoard = arrivals[k-1][conn.from]+r.options.TransferPenalty <= conn.departure
+				}
+				if !canBoard {
+					continue
+				}
+				boarded[k][conn.trip] = index
+			}
+
+			if conn.arrival >= arrivals[k][conn.to] {
+				continue
+			}
+
+			leg := csaLeg{enter: boarded[k][conn.trip], exit: index, level: k}
+			for l := k; l < levels && conn.arrival < arrivals[l][conn.to]; l++ {
+				arrivals[l][conn.to] = conn.arrival
+				legs[l][conn.to] = leg
+			}
+		}
+	}
+
+	// Select the best number of transfers
+	best := -1
+	for k := 0; k < levels; k++ {
+		if arrivals[k][end] == never {
+			continue
+		}
+		if best < 0 || arrivals[k][end]+time.Duration(k)*r.options.TransferPenalty < arrivals[best][end]+time.Duration(best)*r.options.TransferPenalty {
+			best = k
+		}
+	}
+
+	if best < 0 {
+		return Itinerary{}, ErrNoPathFound
+	}
+
+	// Walk back the legs and reverse the hops in travel order
+	hops := []hop{}
+	cursor := end
+	for k := best; cursor != start; {
+
+		leg := legs[k][cursor]
+		exit := r.scanned(leg.exit)
+		day := days[leg.exit/len(r.connections)]
+		trip := r.trips[r.connections[leg.exit%len(r.connections)].trip]
+
+		// The connections of a run are all on the same day
+		for index := leg.exit; index >= leg.enter; index-- {
+			if r.scanned(index).trip != exit.trip {
+				continue
+			}
+			conn := r.connections[index%len(r.connections)]
+
+			hops = append(hops, hop{
+				from:      r.stops[conn.from],
+				to:        r.stops[conn.to],
+				routeID:   trip.routeID,
+				route:     trip.route,
+				details:   trip.details,
+				line:      trip.line,
+				departure: dataprovider.StopTime{Time: conn.departure}.On(day),
+				arrival:   dataprovider.StopTime{Time: conn.arrival}.On(day),
+			})
+		}
+
+		cursor = r.scanned(leg.enter).from
+		k = leg.level - 1
+		if k < 0 && cursor != start {
+			return Itinerary{}, ErrNoPathFound
+		}
+	}
+
+	for i, j := 0, len(hops)-1; i < j; i, j = i+1, j-1 {
+		hops[i], hops[j] = hops[j], hops[i]
+	}
+
+	return newItinerary(r.stops[start], r.stops[end], hops), nil
+}
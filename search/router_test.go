@@ -0,0 +1,188 @@
+package search
+
+import (
+	"testing"
+	"time"
+
+	"github.com/gophersch/tlgo"
+	"github.com/yageek/tl-ai/dataprovider"
+	"github.com/yageek/tl-ai/storage"
+)
+
+var zurich = mustLoadLocation("Europe/Zurich")
+
+func mustLoadLocation(name string) *time.Location {
+	location, err := time.LoadLocation(name)
+	if err != nil {
+		return time.UTC
+	}
+	return location
+}
+
+// timetableStore holds line 1 from A to C through B and line 2 from B to
+// D. Both run on weekdays, only line 1 runs on Sundays.
+func timetableStore() *storage.Store {
+
+	trip := func(day dataprovider.ServiceDay, start time.Duration, stops ...string) dataprovider.Trip {
+		t := dataprovider.Trip{Day: day}
+		for i, stop := range stops {
+			t.StopTimes = append(t.StopTimes, dataprovider.StopTime{StopAreaName: stop, Time: start + time.Duration(i)*5*time.Minute})
+		}
+		return t
+	}
+
+	return storage.NewStore(dataprovider.APIRawData{
+		Stops: []tlgo.Stop{
+			{ID: "a", Name: "A"},
+			{ID: "b", Name: "B"},
+			{ID: "c", Name: "C"},
+			{ID: "d", Name: "D"},
+		},
+		Lines: []tlgo.Line{
+			{ID: "L1", ShortName: "1"},
+			{ID: "L2", ShortName: "2"},
+		},
+		RoutesByLineID: map[string][]tlgo.Route{
+			"L1": {{ID: "r1", CityDestinationStopName: "C"}},
+			"L2": {{ID: "r2", CityDestinationStopName: "D"}},
+		},
+		RoutesDetailsByRouteID: map[string]tlgo.RouteDetails{
+			"r1": {LineID: "L1", Stops: []tlgo.StopRouteDetails{{StopAreaName: "A"}, {StopAreaName: "B"}, {StopAreaName: "C"}}},
+			"r2": {LineID: "L2", Stops: []tlgo.StopRouteDetails{{StopAreaName: "B"}, {StopAreaName: "D"}}},
+		},
+		TripsByRouteID: map[string][]dataprovider.Trip{
+			"r1": {
+				trip(dataprovider.Weekdays, 8*time.Hour, "A", "B", "C"),
+				trip(dataprovider.Sundays, 9*time.Hour, "A", "B", "C"),
+			},
+			"r2": {
+				trip(dataprovider.Weekdays, 8*time.Hour+10*time.Minute, "B", "D"),
+			},
+		},
+	})
+}
+
+func TestNewRouterWithoutTimetable(t *testing.T) {
+	if _, err := NewRouter(*testStore(), DefaultRouterOptions); err != ErrNoTimetable {
+		t.Errorf("expected %v, got %v", ErrNoTimetable, err)
+	}
+}
+
+func TestFindEarliestArrivalPath(t *testing.T) {
+
+	router, err := NewRouter(*timetableStore(), DefaultRouterOptions)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	monday := time.Date(2026, 10, 19, 7, 50, 0, 0, zurich)
+	itinerary, err := router.FindEarliestArrivalPath("A", "D", monday)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(itinerary.Legs) != 2 {
+		t.Fatalf("expected two legs, got %d", len(itinerary.Legs))
+	}
+	if first := itinerary.Legs[0]; first.Line.ShortName != "1" || !first.Departure.Equal(time.Date(2026, 10, 19, 8, 0, 0, 0, zurich)) {
+		t.Errorf("expected line 1 at 8:00, got line %s at %s", first.Line.ShortName, first.Departure)
+	}
+	if last := itinerary.Legs[1]; last.Line.ShortName != "2" || !last.Arrival.Equal(time.Date(2026, 10, 19, 8, 15, 0, 0, zurich)) {
+		t.Errorf("expected line 2 arriving at 8:15, got line %s at %s", last.Line.ShortName, last.Arrival)
+	}
+
+	// The first trips of the next day follow the last ones of the day
+	late := time.Date(2026, 10, 19, 8, 1, 0, 0, zurich)
+	itinerary, err = router.FindEarliestArrivalPath("A", "D", late)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if last := itinerary.Legs[len(itinerary.Legs)-1]; !last.Arrival.Equal(time.Date(2026, 10, 20, 8, 15, 0, 0, zurich)) {
+		t.Errorf("expected to arrive on tuesday at 8:15, got %s", last.Arrival)
+	}
+
+	// Line 2 does not run on Sundays, the clock going back that day
+	sunday := time.Date(2026, 10, 25, 7, 50, 0, 0, zurich)
+	itinerary, err = router.FindEarliestArrivalPath("A", "D", sunday)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if last := itinerary.Legs[len(itinerary.Legs)-1]; !last.Arrival.Equal(time.Date(2026, 10, 26, 8, 15, 0, 0, zurich)) {
+		t.Errorf("expected to arrive on monday at 8:15, got %s", last.Arrival)
+	}
+
+	itinerary, err = router.FindEarliestArrivalPath("A", "C", sunday)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if first := itinerary.Legs[0]; !first.Departure.Equal(time.Date(2026, 10, 25, 9, 0, 0, 0, zurich)) {
+		t.Errorf("expected the sunday trip at 9:00, got %s", first.Departure)
+	}
+
+	// Nothing runs on Saturdays, the sunday trip is taken
+	saturday := time.Date(2026, 10, 24, 7, 50, 0, 0, zurich)
+	itinerary, err = router.FindEarliestArrivalPath("A", "C", saturday)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if first := itinerary.Legs[0]; !first.Departure.Equal(time.Date(2026, 10, 25, 9, 0, 0, 0, zurich)) {
+		t.Errorf("expected the sunday trip at 9:00, got %s", first.Departure)
+	}
+
+	// Line 2 does not run before monday
+	if _, err := router.FindEarliestArrivalPath("A", "D", saturday); err != ErrNoPathFound {
+		t.Errorf("expected no path until sunday, got %v", err)
+	}
+	if _, err := router.FindEarliestArrivalPath("D", "A", monday); err != ErrNoPathFound {
+		t.Errorf("expected no path from D, got %v", err)
+	}
+}
+
+func TestFindEarliestArrivalPathSimultaneousChange(t *testing.T) {
+
+	// Line 3 reaches F when line 4 leaves it, both sampled at the same minute
+	store := storage.NewStore(dataprovider.APIRawData{
+		Stops: []tlgo.Stop{
+			{ID: "e", Name: "E"},
+			{ID: "f", Name: "F"},
+			{ID: "g", Name: "G"},
+		},
+		Lines: []tlgo.Line{
+			{ID: "L3", ShortName: "3"},
+			{ID: "L4", ShortName: "4"},
+		},
+		RoutesByLineID: map[string][]tlgo.Route{
+			"L3": {{ID: "r3", CityDestinationStopName: "F"}},
+			"L4": {{ID: "r4", CityDestinationStopName: "G"}},
+		},
+		RoutesDetailsByRouteID: map[string]tlgo.RouteDetails{
+			"r3": {LineID: "L3", Stops: []tlgo.StopRouteDetails{{StopAreaName: "E"}, {StopAreaName: "F"}}},
+			"r4": {LineID: "L4", Stops: []tlgo.StopRouteDetails{{StopAreaName: "F"}, {StopAreaName: "G"}}},
+		},
+		TripsByRouteID: map[string][]dataprovider.Trip{
+			"r3": {{Day: dataprovider.EveryDay, StopTimes: []dataprovider.StopTime{
+				{StopAreaName: "E", Time: 8 * time.Hour},
+				{StopAreaName: "F", Time: 8 * time.Hour},
+			}}},
+			"r4": {{Day: dataprovider.EveryDay, StopTimes: []dataprovider.StopTime{
+				{StopAreaName: "F", Time: 8 * time.Hour},
+				{StopAreaName: "G", Time: 8*time.Hour + 5*time.Minute},
+			}}},
+		},
+	})
+
+	// The connections being built from a map, the order of the routes varies
+	for i := 0; i < 10; i++ {
+		router, err := NewRouter(*store, RouterOptions{TransferPenalty: 0, MaxTransfers: 1})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		itinerary, err := router.FindEarliestArrivalPath("E", "G", time.Date(2026, 10, 19, 7, 50, 0, 0, zurich))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(itinerary.Legs) != 2 || !itinerary.Legs[1].Arrival.Equal(time.Date(2026, 10, 19, 8, 5, 0, 0, zurich)) {
+			t.Fatalf("expected to change at F and arrive at 8:05, got %+v", itinerary.Legs)
+		}
+	}
+}
@@ -0,0 +1,309 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/yageek/tl-ai/assistant"
+	"github.com/yageek/tl-ai/i18n"
+)
+
+// Models of the Alexa Skills Kit requests and responses
+// See https://developer.amazon.com/docs/custom-skills/request-and-response-json-reference.html
+
+const (
+	alexaLaunchRequest       = "LaunchRequest"
+	alexaIntentRequest       = "IntentRequest"
+	alexaSessionEndedRequest = "SessionEndedRequest"
+
+	alexaHelpIntent   = "AMAZON.HelpIntent"
+	alexaStopIntent   = "AMAZON.StopIntent"
+	alexaCancelIntent = "AMAZON.CancelIntent"
+
+	// alexaContextsKey holds the Dialogflow-like contexts in the session attributes
+	alexaContextsKey   = "contexts"
+	alexaSessionPrefix = "alexa"
+)
+
+// alexaSlotParameters maps the slots of the skill, whose names can not
+// hold dashes, to the parameters of the Dialogflow agent.
+var alexaSlotParameters = map[string]string{
+	"line":        LineNameKey,
+	"origin":      StopOriginKey,
+	"direction":   StopDirectionKey,
+	"destination": StopDestinationKey,
+	"count":       DepartureCountKey,
+	"time":        DepartureTimeKey,
+	"stop":        StopNameKey,
+	"ordinal":     OrdinalKey,
+}
+
+type alexaRequestEnvelope struct {
+	Version string       `json:"version"`
+	Session alexaSession `json:"session"`
+	Context alexaContext `json:"context"`
+	Request alexaRequest `json:"request"`
+}
+
+type alexaSession struct {
+	New         bool                   `json:"new"`
+	SessionID   string                 `json:"sessionId"`
+	Application alexaApplication       `json:"application"`
+	User        alexaUser              `json:"user"`
+	Attributes  map[string]interface{} `json:"attributes"`
+}
+
+type alexaApplication struct {
+	ApplicationID string `json:"applicationId"`
+}
+
+type alexaUser struct {
+	UserID string `json:"userId"`
+}
+
+type alexaContext struct {
+	System      alexaSystem       `json:"System"`
+	Geolocation *alexaGeolocation `json:"Geolocation"`
+}
+
+type alexaSystem struct {
+	Application alexaApplication `json:"application"`
+}
+
+type alexaGeolocation struct {
+	Coordinate struct {
+		Latitude  float64 `json:"latitudeInDegrees"`
+		Longitude float64 `json:"longitudeInDegrees"`
+	} `json:"coordinate"`
+}
+
+type alexaRequest struct {
+	Type      string      `json:"type"`
+	RequestID string      `json:"requestId"`
+	Timestamp string      `json:"timestamp"`
+	Locale    string      `json:"locale"`
+	Intent    alexaIntent `json:"intent"`
+	Reason    string      `json:"reason"`
+}
+
+type alexaIntent struct {
+	Name  string               `json:"name"`
+	Slots map[string]alexaSlot `json:"slots"`
+}
+
+type alexaSlot struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+type alexaResponseEnvelope struct {
+	Version           string                 `json:"version"`
+	SessionAttributes map[string]interface{} `json:"sessionAttributes,omitempty"`
+	Response          alexaResponse          `json:"response"`
+}
+
+type alexaResponse struct {
+	OutputSpeech     *alexaOutputSpeech `json:"outputSpeech,omitempty"`
+	Reprompt         *alexaReprompt     `json:"reprompt,omitempty"`
+	ShouldEndSession bool               `json:"shouldEndSession"`
+}
+
+type alexaOutputSpeech struct {
+	Type string `json:"type"`
+	SSML string `json:"ssml"`
+}
+
+type alexaReprompt struct {
+	OutputSpeech alexaOutputSpeech `json:"outputSpeech"`
+}
+
+// alexaHandler answers the requests of the Alexa skill with the same
+// business logic as the Dialogflow webhook.
+func (h *webhook) alexaHandler(w http.ResponseWriter, r *http.Request) {
+
+	req := alexaRequestEnvelope{}
+	err := json.NewDecoder(r.Body).Decode(&req)
+	if err != nil {
+		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+		return
+	}
+	defer r.Body.Close()
+
+	switch req.Request.Type {
+	case alexaLaunchRequest:
+		alexaRespond(w, assistant.Plain(i18n.NewPrinter(i18n.ParseLanguage(req.Request.Locale)).Sprintf(i18n.Welcome)), assistant.Utterance{}, nil, false)
+	case alexaSessionEndedRequest:
+		log.Printf("Alexa session ended: %s\n", req.Request.Reason)
+		alexaRespond(w, assistant.Utterance{}, assistant.Utterance{}, nil, true)
+	case alexaIntentRequest:
+		ctx, cancel := h.requestContext(r)
+		defer cancel()
+		h.handleAlexaIntent(ctx, w, req)
+	default:
+		http.Error(w, "Unkown request type", http.StatusBadRequest)
+	}
+}
+
+func (h *webhook) handleAlexaIntent(ctx context.Context, w http.ResponseWriter, req alexaRequestEnvelope) {
+
+	p := i18n.NewPrinter(i18n.ParseLanguage(req.Request.Locale))
+
+	switch req.Request.Intent.Name {
+	case alexaHelpIntent:
+		alexaRespond(w, assistant.Plain(p.Sprintf(i18n.Welcome)), assistant.Utterance{}, nil, false)
+		return
+	case alexaStopIntent, alexaCancelIntent:
+		alexaRespond(w, assistant.Plain(p.Sprintf(i18n.Goodbye)), assistant.Utterance{}, nil, true)
+		return
+	}
+
+	// Run the Dialogflow logic and translate its answer
+	f := alexaFullfillment(req)
+	resp, err := h.dispatchIntent(ctx, f)
+	if err != nil {
+		log.Printf("The intent %s failed: %v\n", req.Request.Intent.Name, err)
+		alexaRespond(w, assistant.Plain(p.Sprintf(i18n.RequestNotUnderstood)), assistant.Utterance{}, nil, true)
+		return
+	}
+
+	// Questions keep the session open for the user to answer, and so do
+	// the departures for the user to ask for the following ones
+	isQuestion, canFollowUp := false, false
+	for _, ctx := range resp.OutputContexts {
+		isQuestion = isQuestion || strings.HasSuffix(ctx.Name, "/contexts/"+stopDisambiguationContext)
+		canFollowUp = canFollowUp || strings.HasSuffix(ctx.Name, "/contexts/"+departureQueryContext)
+	}
+
+	reprompt := assistant.Utterance{}
+	if canFollowUp && !isQuestion {
+		reprompt = assistant.Plain(p.Sprintf(i18n.FollowUpReprompt))
+	}
+	alexaRespond(w, resp.speech(), reprompt, carriedContexts(f.QueryResult.OutputContexts, resp.OutputContexts), !isQuestion && !canFollowUp)
+}
+
+// carriedContexts ages the contexts of the session as Dialogflow does:
+// the contexts of the answer replace the previous ones, which are kept
+// until their lifespan ends.
+func carriedContexts(previous []outputContext, answered []outputContext) []outputContext {
+
+	contexts := append([]outputContext{}, answered...)
+	for _, ctx := range previous {
+		isReplaced := false
+		for _, other := range answered {
+			isReplaced = isReplaced || other.Name == ctx.Name
+		}
+
+		ctx.LifespanCount--
+		if !isReplaced && ctx.LifespanCount > 0 {
+			contexts = append(contexts, ctx)
+		}
+	}
+	return contexts
+}
+
+// alexaFullfillment translates an intent request of the skill into the
+// Dialogflow request handled by dispatchIntent.
+func alexaFullfillment(req alexaRequestEnvelope) fullfillment {
+
+	f := fullfillment{
+		Session: alexaSessionPrefix + "/" + req.Session.SessionID,
+		QueryResult: queryResult{
+			LanguageCode: req.Request.Locale,
+			Intent:       intent{DisplayName: req.Request.Intent.Name},
+			Parameters:   map[string]interface{}{},
+		},
+	}
+
+	for name, slot := range req.Request.Intent.Slots {
+		key, isKnown := alexaSlotParameters[name]
+		if !isKnown || slot.Value == "" {
+			continue
+		}
+
+		switch key {
+		case StopOriginKey, StopDirectionKey, StopDestinationKey:
+			f.QueryResult.Parameters[key] = map[string]interface{}{StopNameKey: slot.Value}
+		case DepartureCountKey, OrdinalKey:
+			if value, err := strconv.ParseFloat(slot.Value, 64); err == nil {
+				f.QueryResult.Parameters[key] = value
+			}
+		case DepartureTimeKey:
+			if at, err := alexaTime(slot.Value, time.Now()); err == nil {
+				f.QueryResult.Parameters[key] = at.Format(time.RFC3339)
+			}
+		default:
+			f.QueryResult.Parameters[key] = slot.Value
+		}
+	}
+
+	// The contexts round trip in the session attributes
+	if raw, hasContexts := req.Session.Attributes[alexaContextsKey]; hasContexts {
+		if b, err := json.Marshal(raw); err == nil {
+			json.Unmarshal(b, &f.QueryResult.OutputContexts)
+		}
+	}
+
+	// The users of both platforms have their own favourites
+	if req.Session.User.UserID != "" {
+		f.OriginalRequest.Payload.User.UserID = alexaSessionPrefix + "/" + req.Session.User.UserID
+	}
+
+	if geolocation := req.Context.Geolocation; geolocation != nil {
+		f.OriginalRequest.Payload.Device.Location = &location{
+			Coordinates: coordinates{
+				Latitude:  geolocation.Coordinate.Latitude,
+				Longitude: geolocation.Coordinate.Longitude,
+			},
+		}
+	}
+
+	return f
+}
+
+// alexaTime converts an AMAZON.TIME value such as "18:07" to the next
+// occurrence of this time.
+func alexaTime(value string, now time.Time) (time.Time, error) {
+
+	clock, err := time.Parse("15:04", value)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	now = now.In(assistant.TimeZone)
+	at := time.Date(now.Year(), now.Month(), now.Day(), clock.Hour(), clock.Minute(), 0, 0, assistant.TimeZone)
+	return nextOccurrence(at, now), nil
+}
+
+// alexaRespond tells the speech, the reprompt being told when the user does
+// not answer while the session is open. The speech is repeated when the
+// reprompt is empty.
+func alexaRespond(w http.ResponseWriter, speech assistant.Utterance, reprompt assistant.Utterance, contexts []outputContext, shouldEndSession bool) {
+
+	resp := alexaResponseEnvelope{
+		Version:  "1.0",
+		Response: alexaResponse{ShouldEndSession: shouldEndSession},
+	}
+
+	if speech.Text != "" {
+		outputSpeech := alexaOutputSpeech{Type: "SSML", SSML: speech.Speak()}
+		resp.Response.OutputSpeech = &outputSpeech
+		if !shouldEndSession {
+			resp.Response.Reprompt = &alexaReprompt{OutputSpeech: outputSpeech}
+			if reprompt.Text != "" {
+				resp.Response.Reprompt.OutputSpeech.SSML = reprompt.Speak()
+			}
+		}
+	}
+
+	if len(contexts) > 0 {
+		resp.SessionAttributes = map[string]interface{}{alexaContextsKey: contexts}
+	}
+
+	w.Header().Set("Content-Type", "application/json; charset=utf8")
+	json.NewEncoder(w).Encode(&resp)
+}
@@ -0,0 +1,153 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/yageek/tl-ai/assistant"
+)
+
+// alexaExchange sends the request to the skill and returns its response
+func alexaExchange(t *testing.T, hook *webhook, req alexaRequestEnvelope) alexaResponseEnvelope {
+	t.Helper()
+
+	b, err := json.Marshal(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	w := httptest.NewRecorder()
+	hook.alexaHandler(w, httptest.NewRequest(http.MethodPost, "/alexa", bytes.NewReader(b)))
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", w.Code)
+	}
+
+	resp := alexaResponseEnvelope{}
+	if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+		t.Fatal(err)
+	}
+	return resp
+}
+
+func alexaIntentFor(name string, slots map[string]string, attributes map[string]interface{}) alexaRequestEnvelope {
+	req := alexaRequestEnvelope{
+		Version: "1.0",
+		Session: alexaSession{SessionID: "session", User: alexaUser{UserID: "user"}, Attributes: attributes},
+		Request: alexaRequest{Type: alexaIntentRequest, Locale: "fr-CH", Intent: alexaIntent{Name: name, Slots: map[string]alexaSlot{}}},
+	}
+	for slot, value := range slots {
+		req.Request.Intent.Slots[slot] = alexaSlot{Name: slot, Value: value}
+	}
+	return req
+}
+
+func TestAlexaJourney(t *testing.T) {
+
+	hook := newTestWebhook(t, nil, time.Second)
+
+	resp := alexaExchange(t, hook, alexaIntentFor(dialogFlowJourneyIntent, map[string]string{
+		"origin":      "Ouchy",
+		"destination": "Gare",
+	}, nil))
+
+	want := "<speak>Depuis Ouchy, prenez la ligne 2 en direction de Gare jusqu'à Gare.</speak>"
+	if resp.Response.OutputSpeech == nil || resp.Response.OutputSpeech.SSML != want {
+		t.Errorf("expected %q, got %+v", want, resp.Response.OutputSpeech)
+	}
+	if !resp.Response.ShouldEndSession {
+		t.Errorf("the session should end after the answer")
+	}
+}
+
+func TestAlexaStopDisambiguation(t *testing.T) {
+
+	hook := newTestWebhook(t, nil, time.Second)
+
+	resp := alexaExchange(t, hook, alexaIntentFor(dialogFlowJourneyIntent, map[string]string{
+		"origin":      "Bessières",
+		"destination": "Gare",
+	}, nil))
+
+	// The question keeps the session open for the user to answer
+	if resp.Response.ShouldEndSession || resp.Response.Reprompt == nil {
+		t.Fatalf("expected the session to stay open, got %+v", resp.Response)
+	}
+
+	resp = alexaExchange(t, hook, alexaIntentFor(dialogFlowStopDisambiguationIntent, map[string]string{"ordinal": "1"}, resp.SessionAttributes))
+	want := "<speak>Depuis Bessières Sud, prenez la ligne 2 en direction de Gare jusqu'à Gare.</speak>"
+	if resp.Response.OutputSpeech == nil || resp.Response.OutputSpeech.SSML != want {
+		t.Errorf("expected %q, got %+v", want, resp.Response.OutputSpeech)
+	}
+}
+
+func TestAlexaTime(t *testing.T) {
+
+	now := time.Date(2026, 10, 18, 19, 0, 0, 0, assistant.TimeZone)
+
+	tests := []struct {
+		value string
+		want  time.Time
+	}{
+		{"20:30", time.Date(2026, 10, 18, 20, 30, 0, 0, assistant.TimeZone)},
+		{"19:00", now},
+		// A time already past today is tomorrow's
+		{"18:00", time.Date(2026, 10, 19, 18, 0, 0, 0, assistant.TimeZone)},
+	}
+
+	for _, test := range tests {
+		at, err := alexaTime(test.value, now)
+		if err != nil {
+			t.Errorf("%s: %v", test.value, err)
+			continue
+		}
+		if !at.Equal(test.want) {
+			t.Errorf("%s: expected %s, got %s", test.value, test.want, at)
+		}
+	}
+}
+
+func TestAlexaDeparturesKeepTheSessionOpen(t *testing.T) {
+
+	hook := newTestWebhook(t, nil, 0)
+
+	resp := alexaExchange(t, hook, alexaIntentFor(dialogFlowNextDepartureIntent, map[string]string{
+		"line":      "2",
+		"origin":    "Flon",
+		"direction": "Gare",
+	}, nil))
+
+	if resp.Response.ShouldEndSession {
+		t.Fatalf("the session should stay open for the follow-ups")
+	}
+	if resp.Response.Reprompt == nil || resp.Response.Reprompt.OutputSpeech.SSML == resp.Response.OutputSpeech.SSML {
+		t.Errorf("expected the follow-ups to be suggested, got %+v", resp.Response.Reprompt)
+	}
+
+	// The follow-up is answered from the contexts of the session
+	first := resp.Response.OutputSpeech.SSML
+	resp = alexaExchange(t, hook, alexaIntentFor(dialogFlowNextDepartureFollowingIntent, nil, resp.SessionAttributes))
+	if resp.Response.OutputSpeech == nil || resp.Response.OutputSpeech.SSML == first {
+		t.Errorf("expected the following departure, got %+v", resp.Response.OutputSpeech)
+	}
+	if resp.Response.ShouldEndSession {
+		t.Errorf("the session should stay open after a follow-up")
+	}
+}
+
+func TestAlexaRespondEndsTheSession(t *testing.T) {
+
+	w := httptest.NewRecorder()
+	alexaRespond(w, assistant.Plain("Au revoir"), assistant.Utterance{}, nil, true)
+
+	resp := alexaResponseEnvelope{}
+	if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+		t.Fatal(err)
+	}
+	if !resp.Response.ShouldEndSession || resp.Response.Reprompt != nil {
+		t.Errorf("expected the session to end without reprompt, got %+v", resp.Response)
+	}
+}
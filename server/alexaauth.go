@@ -0,0 +1,316 @@
+package main
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/rsa"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Verification of the requests sent by Alexa
+// See https://developer.amazon.com/docs/custom-skills/host-a-custom-skill-as-a-web-service.html
+
+const (
+	alexaCertificateHost   = "s3.amazonaws.com"
+	alexaCertificatePath   = "/echo.api/"
+	alexaCertificateDomain = "echo-api.amazon.com"
+
+	// alexaTimestampTolerance is the maximum age of a request
+	alexaTimestampTolerance = 150 * time.Second
+
+	// The endpoint being public, the sizes read from the requests and
+	// the certificates are bounded
+	alexaMaxRequestSize     = 128 << 10
+	alexaMaxCertificateSize = 64 << 10
+	// alexaMaxCachedChains is the number of certificate chains kept in
+	// memory, Amazon signing with a single one at a time
+	alexaMaxCachedChains = 8
+)
+
+var (
+	ErrInvalidCertificateURL = errors.New("invalid certificate URL")
+	ErrInvalidCertificate    = errors.New("invalid certificate")
+	ErrInvalidSignature      = errors.New("invalid signature")
+	ErrInvalidTimestamp      = errors.New("request is too old")
+	ErrInvalidApplication    = errors.New("unexpected application")
+)
+
+// certificateSource provides the certificate chain the request has been
+// signed with from its URL.
+type certificateSource interface {
+	CertificateChain(certURL string) ([]*x509.Certificate, error)
+}
+
+// httpCertificateSource downloads the certificate chains and keeps the
+// last ones in memory. The URLs are expected to be normalised by
+// validateCertificateURL, so their variants share the same chain.
+type httpCertificateSource struct {
+	client *http.Client
+	mutex  sync.Mutex
+	chains map[string][]*x509.Certificate
+}
+
+func newHTTPCertificateSource() *httpCertificateSource {
+	return &httpCertificateSource{
+		client: &http.Client{Timeout: 5 * time.Second},
+		chains: map[string][]*x509.Certificate{},
+	}
+}
+
+func (s *httpCertificateSource) CertificateChain(certURL string) ([]*x509.Certificate, error) {
+
+	s.mutex.Lock()
+	chain, hasChain := s.chains[certURL]
+	s.mutex.Unlock()
+	if hasChain {
+		return chain, nil
+	}
+
+	resp, err := s.client.Get(certURL)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("can not download %s: %s", certURL, resp.Status)
+	}
+
+	b, err := io.ReadAll(io.LimitReader(resp.Body, alexaMaxCertificateSize+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(b) > alexaMaxCertificateSize {
+		return nil, fmt.Errorf("the certificate chain %s is too large", certURL)
+	}
+
+	chain, err = parseCertificateChain(b)
+	if err != nil {
+		return nil, err
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if len(s.chains) >= alexaMaxCachedChains {
+		for cached := range s.chains {
+			delete(s.chains, cached)
+			break
+		}
+	}
+	s.chains[certURL] = chain
+	return chain, nil
+}
+
+// fileCertificateSource reads the certificate chains from a directory,
+// the file being named as the last element of the URL.
+type fileCertificateSource struct {
+	dir string
+}
+
+func (s fileCertificateSource) CertificateChain(certURL string) ([]*x509.Certificate, error) {
+
+	u, err := url.Parse(certURL)
+	if err != nil {
+		return nil, err
+	}
+
+	b, err := os.ReadFile(filepath.Join(s.dir, path.Base(u.Path)))
+	if err != nil {
+		return nil, err
+	}
+	return parseCertificateChain(b)
+}
+
+// newCertificateSource returns the source configured by the environment:
+// the ALEXA_CERTIFICATES_DIR directory when set, Amazon otherwise.
+func newCertificateSource() certificateSource {
+	if dir := os.Getenv("ALEXA_CERTIFICATES_DIR"); dir != "" {
+		log.Printf("Reading the Alexa certificates from %s", dir)
+		return fileCertificateSource{dir: dir}
+	}
+	return newHTTPCertificateSource()
+}
+
+func parseCertificateChain(b []byte) ([]*x509.Certificate, error) {
+
+	chain := []*x509.Certificate{}
+	for {
+		var block *pem.Block
+		block, b = pem.Decode(b)
+		if block == nil {
+			break
+		}
+		if block.Type != "CERTIFICATE" {
+			continue
+		}
+
+		cert, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			return nil, err
+		}
+		chain = append(chain, cert)
+	}
+
+	if len(chain) == 0 {
+		return nil, ErrInvalidCertificate
+	}
+	return chain, nil
+}
+
+// alexaVerifier checks the requests have been sent by Alexa for the skill
+type alexaVerifier struct {
+	source certificateSource
+	// roots are the trusted authorities, the system ones when nil
+	roots *x509.CertPool
+	// applicationID is the ID of the skill, any skill is accepted when empty
+	applicationID string
+	now           func() time.Time
+}
+
+func newAlexaVerifier(source certificateSource, applicationID string) *alexaVerifier {
+	return &alexaVerifier{source: source, applicationID: applicationID, now: time.Now}
+}
+
+// verify checks the signature of the body and the freshness of the request
+func (v *alexaVerifier) verify(header http.Header, body []byte) error {
+
+	certURL, err := validateCertificateURL(header.Get("SignatureCertChainUrl"))
+	if err != nil {
+		return err
+	}
+
+	chain, err := v.source.CertificateChain(certURL)
+	if err != nil {
+		return fmt.Errorf("%v: %v", ErrInvalidCertificate, err)
+	}
+
+	leaf := chain[0]
+	intermediates := x509.NewCertPool()
+	for _, cert := range chain[1:] {
+		intermediates.AddCert(cert)
+	}
+
+	_, err = leaf.Verify(x509.VerifyOptions{
+		DNSName:       alexaCertificateDomain,
+		Intermediates: intermediates,
+		Roots:         v.roots,
+		CurrentTime:   v.now(),
+	})
+	if err != nil {
+		return fmt.Errorf("%v: %v", ErrInvalidCertificate, err)
+	}
+
+	publicKey, isRSA := leaf.PublicKey.(*rsa.PublicKey)
+	if !isRSA {
+		return ErrInvalidCertificate
+	}
+
+	// Signature-256 supersedes the SHA-1 signature
+	hash, encoded := crypto.SHA256, header.Get("Signature-256")
+	if encoded == "" {
+		hash, encoded = crypto.SHA1, header.Get("Signature")
+	}
+
+	signature, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil || len(signature) == 0 {
+		return ErrInvalidSignature
+	}
+
+	var digest []byte
+	if hash == crypto.SHA256 {
+		sum := sha256.Sum256(body)
+		digest = sum[:]
+	} else {
+		sum := sha1.Sum(body)
+		digest = sum[:]
+	}
+
+	if err := rsa.VerifyPKCS1v15(publicKey, hash, digest, signature); err != nil {
+		return ErrInvalidSignature
+	}
+
+	envelope := alexaRequestEnvelope{}
+	if err := json.Unmarshal(body, &envelope); err != nil {
+		return err
+	}
+
+	timestamp, err := time.Parse(time.RFC3339, envelope.Request.Timestamp)
+	if err != nil {
+		return ErrInvalidTimestamp
+	}
+	if age := v.now().Sub(timestamp); age > alexaTimestampTolerance || age < -alexaTimestampTolerance {
+		return ErrInvalidTimestamp
+	}
+
+	if v.applicationID != "" && envelope.Context.System.Application.ApplicationID != v.applicationID && envelope.Session.Application.ApplicationID != v.applicationID {
+		return ErrInvalidApplication
+	}
+
+	return nil
+}
+
+// validateCertificateURL checks the certificate is hosted by Amazon and
+// returns its normalised URL, e.g. "https://s3.amazonaws.com/echo.api/cert.pem"
+// for "HTTPS://s3.amazonaws.com:443/echo.api/../echo.api/cert.pem".
+func validateCertificateURL(certURL string) (string, error) {
+
+	u, err := url.Parse(certURL)
+	if err != nil {
+		return "", ErrInvalidCertificateURL
+	}
+
+	if !strings.EqualFold(u.Scheme, "https") || !strings.EqualFold(u.Hostname(), alexaCertificateHost) {
+		return "", ErrInvalidCertificateURL
+	}
+	if port := u.Port(); port != "" && port != "443" {
+		return "", ErrInvalidCertificateURL
+	}
+
+	certPath := path.Clean(u.Path)
+	if !strings.HasPrefix(certPath, alexaCertificatePath) {
+		return "", ErrInvalidCertificateURL
+	}
+
+	normalised := url.URL{Scheme: "https", Host: alexaCertificateHost, Path: certPath}
+	return normalised.String(), nil
+}
+
+// alexaAuth only passes the requests verified as sent by Alexa
+func alexaAuth(verifier *alexaVerifier, pass http.HandlerFunc) http.HandlerFunc {
+
+	return func(w http.ResponseWriter, r *http.Request) {
+
+		body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, alexaMaxRequestSize))
+		r.Body.Close()
+		if err != nil {
+			http.Error(w, "Invalid body", http.StatusRequestEntityTooLarge)
+			return
+		}
+
+		if err := verifier.verify(r.Header, body); err != nil {
+			log.Printf("Alexa request rejected: %v\n", err)
+			http.Error(w, "verification failed", http.StatusBadRequest)
+			return
+		}
+
+		r.Body = io.NopCloser(bytes.NewReader(body))
+		pass(w, r)
+	}
+}
@@ -0,0 +1,217 @@
+package main
+
+import (
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"encoding/pem"
+	"fmt"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestValidateCertificateURL(t *testing.T) {
+
+	const normalised = "https://s3.amazonaws.com/echo.api/echo-api-cert.pem"
+
+	valid := []string{
+		"https://s3.amazonaws.com/echo.api/echo-api-cert.pem",
+		"HTTPS://S3.AMAZONAWS.COM/echo.api/echo-api-cert.pem",
+		"https://s3.amazonaws.com:443/echo.api/echo-api-cert.pem",
+		"https://s3.amazonaws.com/echo.api/../echo.api/echo-api-cert.pem",
+		"https://s3.amazonaws.com/echo.api/echo-api-cert.pem?variant=1#fragment",
+	}
+	for _, certURL := range valid {
+		got, err := validateCertificateURL(certURL)
+		if err != nil {
+			t.Errorf("%s: %v", certURL, err)
+			continue
+		}
+		if got != normalised {
+			t.Errorf("%s: expected %s, got %s", certURL, normalised, got)
+		}
+	}
+
+	invalid := []string{
+		"http://s3.amazonaws.com/echo.api/echo-api-cert.pem",
+		"https://notamazon.com/echo.api/echo-api-cert.pem",
+		"https://s3.amazonaws.com/EcHo.aPi/echo-api-cert.pem",
+		"https://s3.amazonaws.com/invalid.path/echo-api-cert.pem",
+		"https://s3.amazonaws.com/echo.api/../invalid.path/echo-api-cert.pem",
+		"https://s3.amazonaws.com:563/echo.api/echo-api-cert.pem",
+	}
+	for _, certURL := range invalid {
+		if _, err := validateCertificateURL(certURL); err != ErrInvalidCertificateURL {
+			t.Errorf("%s: expected %v, got %v", certURL, ErrInvalidCertificateURL, err)
+		}
+	}
+}
+
+func TestAlexaVerifier(t *testing.T) {
+
+	now := time.Date(2026, 10, 18, 19, 0, 0, 0, time.UTC)
+
+	key, err := rsa.GenerateKey(rand.Reader, 1024)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: alexaCertificateDomain},
+		DNSNames:              []string{alexaCertificateDomain},
+		NotBefore:             now.Add(-time.Hour),
+		NotAfter:              now.Add(time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The self-signed certificate is served from a directory and trusted
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "echo-api-cert.pem"), pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	verifier := newAlexaVerifier(fileCertificateSource{dir: dir}, "skill")
+	verifier.roots = x509.NewCertPool()
+	verifier.roots.AddCert(cert)
+	verifier.now = func() time.Time { return now }
+
+	request := func(timestamp time.Time, applicationID string) []byte {
+		return []byte(fmt.Sprintf(`{"session": {"application": {"applicationId": %q}}, "request": {"timestamp": %q}}`, applicationID, timestamp.Format(time.RFC3339)))
+	}
+	signed := func(body []byte) http.Header {
+		digest := sha256.Sum256(body)
+		signature, err := rsa.SignPKCS1v15(rand.Reader, key, crypto.SHA256, digest[:])
+		if err != nil {
+			t.Fatal(err)
+		}
+		header := http.Header{}
+		header.Set("SignatureCertChainUrl", "https://s3.amazonaws.com/echo.api/echo-api-cert.pem")
+		header.Set("Signature-256", base64.StdEncoding.EncodeToString(signature))
+		return header
+	}
+
+	body := request(now, "skill")
+	if err := verifier.verify(signed(body), body); err != nil {
+		t.Errorf("expected the request to be verified, got %v", err)
+	}
+
+	tampered := request(now, "other")
+	if err := verifier.verify(signed(body), tampered); err != ErrInvalidSignature {
+		t.Errorf("expected %v, got %v", ErrInvalidSignature, err)
+	}
+
+	tests := []struct {
+		name string
+		body []byte
+		want error
+	}{
+		{"old request", request(now.Add(-time.Hour), "skill"), ErrInvalidTimestamp},
+		{"other skill", request(now, "other"), ErrInvalidApplication},
+	}
+	for _, test := range tests {
+		if err := verifier.verify(signed(test.body), test.body); err != test.want {
+			t.Errorf("%s: expected %v, got %v", test.name, test.want, err)
+		}
+	}
+}
+
+func TestAlexaAuthRejectsLargeBodies(t *testing.T) {
+
+	passed := false
+	handler := alexaAuth(newAlexaVerifier(fileCertificateSource{dir: t.TempDir()}, ""), func(w http.ResponseWriter, r *http.Request) {
+		passed = true
+	})
+
+	body := strings.NewReader(strings.Repeat(" ", alexaMaxRequestSize+1))
+	w := httptest.NewRecorder()
+	handler(w, httptest.NewRequest(http.MethodPost, "/alexa", body))
+
+	if w.Code != http.StatusRequestEntityTooLarge {
+		t.Errorf("expected %d, got %d", http.StatusRequestEntityTooLarge, w.Code)
+	}
+	if passed {
+		t.Errorf("the request should not have been passed")
+	}
+}
+
+// testCertificatePEM returns a self-signed certificate
+func testCertificatePEM(t *testing.T) []byte {
+	t.Helper()
+
+	key, err := rsa.GenerateKey(rand.Reader, 1024)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: alexaCertificateDomain},
+		DNSNames:     []string{alexaCertificateDomain},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+}
+
+func TestHTTPCertificateSourceBounds(t *testing.T) {
+
+	certificate := testCertificatePEM(t)
+	downloads := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		downloads++
+		if r.URL.Path == "/large.pem" {
+			w.Write([]byte(strings.Repeat("-", alexaMaxCertificateSize+1)))
+			return
+		}
+		w.Write(certificate)
+	}))
+	defer server.Close()
+
+	source := newHTTPCertificateSource()
+
+	if _, err := source.CertificateChain(server.URL + "/large.pem"); err == nil {
+		t.Errorf("expected the large chain to be refused")
+	}
+
+	for i := 0; i < alexaMaxCachedChains*2; i++ {
+		if _, err := source.CertificateChain(fmt.Sprintf("%s/cert-%d.pem", server.URL, i)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if len(source.chains) > alexaMaxCachedChains {
+		t.Errorf("expected at most %d cached chains, got %d", alexaMaxCachedChains, len(source.chains))
+	}
+
+	// The chains still cached are not downloaded again
+	downloads = 0
+	for certURL := range source.chains {
+		if _, err := source.CertificateChain(certURL); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if downloads != 0 {
+		t.Errorf("expected the cached chains to be reused, got %d downloads", downloads)
+	}
+}
@@ -0,0 +1,54 @@
+package main
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/yageek/tl-ai/assistant"
+)
+
+const (
+	DepartureTimeKey = "departure-time"
+)
+
+// departureTimeFrom returns the departure time asked in the parameters
+// or the zero time when none is asked. The parameter is either an
+// @sys.time or an @sys.date-time value, the latter being sometimes
+// wrapped in an object.
+func departureTimeFrom(parameters map[string]interface{}, now time.Time) (time.Time, error) {
+
+	value := ""
+	switch raw := parameters[DepartureTimeKey].(type) {
+	case string:
+		value = raw
+	case map[string]interface{}:
+		for _, key := range []string{"date_time", "startDateTime", "startTime"} {
+			if s, isString := raw[key].(string); isString && s != "" {
+				value = s
+				break
+			}
+		}
+	}
+
+	if value == "" {
+		return time.Time{}, nil
+	}
+
+	at, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("unexpected time %q: %v", value, err)
+	}
+	return nextOccurrence(at, now), nil
+}
+
+// nextOccurrence moves a time of today already past to tomorrow, "à 18h"
+// asked at 19h meaning the next day. The times of the other days are
+// kept as they are asked.
+func nextOccurrence(at time.Time, now time.Time) time.Time {
+
+	at, now = at.In(assistant.TimeZone), now.In(assistant.TimeZone)
+	if at.YearDay() == now.YearDay() && at.Year() == now.Year() && at.Before(now.Add(-time.Minute)) {
+		return at.AddDate(0, 0, 1)
+	}
+	return at
+}
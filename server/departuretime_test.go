@@ -0,0 +1,62 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"github.com/yageek/tl-ai/assistant"
+)
+
+func TestDepartureTimeFrom(t *testing.T) {
+
+	now := time.Date(2026, 10, 18, 19, 0, 0, 0, assistant.TimeZone)
+
+	tests := []struct {
+		name  string
+		value interface{}
+		want  time.Time
+	}{
+		{"none", nil, time.Time{}},
+		{"later today", "2026-10-18T20:30:00+02:00", time.Date(2026, 10, 18, 20, 30, 0, 0, assistant.TimeZone)},
+		{"past today", "2026-10-18T18:00:00+02:00", time.Date(2026, 10, 19, 18, 0, 0, 0, assistant.TimeZone)},
+		{"just asked", "2026-10-18T19:00:00+02:00", now},
+		{"tomorrow", map[string]interface{}{"date_time": "2026-10-19T08:00:00+02:00"}, time.Date(2026, 10, 19, 8, 0, 0, 0, assistant.TimeZone)},
+	}
+
+	for _, test := range tests {
+		parameters := map[string]interface{}{}
+		if test.value != nil {
+			parameters[DepartureTimeKey] = test.value
+		}
+
+		at, err := departureTimeFrom(parameters, now)
+		if err != nil {
+			t.Errorf("%s: %v", test.name, err)
+			continue
+		}
+		if !at.Equal(test.want) {
+			t.Errorf("%s: expected %s, got %s", test.name, test.want, at)
+		}
+	}
+
+	if _, err := departureTimeFrom(map[string]interface{}{DepartureTimeKey: "ce soir"}, now); err == nil {
+		t.Errorf("expected the invalid time to be reported")
+	}
+}
+
+func TestAlexaTimeMatchesDialogflow(t *testing.T) {
+
+	now := time.Date(2026, 10, 18, 19, 0, 0, 0, assistant.TimeZone)
+
+	alexa, err := alexaTime("18:00", now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	dialogflow, err := departureTimeFrom(map[string]interface{}{DepartureTimeKey: "2026-10-18T18:00:00+02:00"}, now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !alexa.Equal(dialogflow) {
+		t.Errorf("Alexa asks for %s and Dialogflow for %s", alexa, dialogflow)
+	}
+}
@@ -1,46 +1,66 @@
 package main
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
+	"image/color"
 	"log"
+	"math"
 	"net/http"
 	"strings"
 	"time"
 
-	"github.com/gophersch/tlgo"
+	"github.com/yageek/tl-ai/assistant"
+	"github.com/yageek/tl-ai/favourites"
+	"github.com/yageek/tl-ai/geo"
+	"github.com/yageek/tl-ai/i18n"
 )
 
 const (
 	dialogFlowNextDepartureIntent = "NextDepartureQuery"
+	dialogFlowJourneyIntent       = "JourneyQuery"
+	dialogFlowNextBusNearMeIntent = "NextBusNearMeQuery"
 	LineNameKey                   = "line-name"
 	StopOriginKey                 = "stop-origin"
 	StopDirectionKey              = "stop-direction"
+	StopDestinationKey            = "stop-destination"
+	DepartureCountKey             = "departure-count"
 )
 
-type fullfillment struct {
-	QueryResult queryResult `json:"queryResult"`
-}
-type queryResult struct {
-	Query              string                 `json:"queryText"`
-	Parameters         map[string]interface{} `json:"parameters"`
-	AllRequiredPresent bool                   `json:"allRequiredParamsPresent"`
-	Intent             intent                 `json:"intent"`
-	Confidence         float32                `json:"intentDetectionConfidence"`
-}
+var (
+	ErrUnknownIntent = errors.New("Unknown intent")
+)
 
-type intent struct {
-	Name        string `json:"name"`
-	DisplayName string `json:"displayName"`
+// webhook answers the requests of the voice platforms, its dependencies
+// being provided by main.
+type webhook struct {
+	core       *assistant.Assistant
+	favourites favourites.Store
+	// timeout bounds the upstream calls of a request, the departures of
+	// the timetable being told once it is elapsed
+	timeout time.Duration
+
+	// publicURL is the URL the server is reached at, used in the images
+	// shown on the screens
+	publicURL string
+	// lineColours are the colours of the lines, which are not provided by
+	// the TL API, configured with LINE_COLOURS such as "9:E2001A,16:00A0E2"
+	lineColours map[string]color.RGBA
 }
 
-type fullFillementResponse struct {
-	Text string `json:"fulfillmentText"`
+// requestContext returns the context of the upstream calls of a request,
+// cancelled when the client goes away or after the timeout.
+func (h *webhook) requestContext(r *http.Request) (context.Context, context.CancelFunc) {
+	if h.timeout <= 0 {
+		return context.WithCancel(r.Context())
+	}
+	return context.WithTimeout(r.Context(), h.timeout)
 }
 
-// indexHandler responds to requests with our greeting.
-func dialogFlowHandler(w http.ResponseWriter, r *http.Request) {
+// dialogFlowHandler answers the fullfillment requests of Dialogflow
+func (h *webhook) dialogFlowHandler(w http.ResponseWriter, r *http.Request) {
 
 	if !strings.HasPrefix(r.Header.Get("Content-Type"), "application/json") {
 		http.Error(w, "Invalid content type", http.StatusBadRequest)
@@ -55,17 +75,40 @@ func dialogFlowHandler(w http.ResponseWriter, r *http.Request) {
 	}
 	defer r.Body.Close()
 
-	if req.QueryResult.Intent.DisplayName == dialogFlowNextDepartureIntent {
-		handleNextDepartureQuery(w, req)
-	} else {
+	ctx, cancel := h.requestContext(r)
+	defer cancel()
+
+	resp, err := h.dispatchIntent(ctx, req)
+	if err != nil {
 		http.Error(w, "Unkown Intent", http.StatusNotFound)
+		return
 	}
+	respond(w, resp)
+}
 
+func (h *webhook) dispatchIntent(ctx context.Context, req fullfillment) (fullFillementResponse, error) {
+
+	switch req.QueryResult.Intent.DisplayName {
+	case dialogFlowNextDepartureIntent, dialogFlowNextBusNearMeIntent:
+		return h.handleNextDepartureQuery(ctx, req), nil
+	case dialogFlowJourneyIntent:
+		return h.handleJourneyQuery(req), nil
+	case dialogFlowStopDisambiguationIntent:
+		return h.handleStopDisambiguation(ctx, req), nil
+	case dialogFlowNextDepartureFollowingIntent, dialogFlowNextDepartureReverseIntent:
+		return h.handleNextDepartureFollowUp(ctx, req), nil
+	case dialogFlowSaveFavouriteIntent:
+		return h.handleSaveFavourite(req), nil
+	case dialogFlowMyBusIntent:
+		return h.handleMyBus(ctx, req), nil
+	default:
+		return fullFillementResponse{}, ErrUnknownIntent
+	}
 }
 
 func stopNameFromMap(m map[string]interface{}) (string, error) {
 
-	key, hasKey := m["stop-name"].(string)
+	key, hasKey := m[StopNameKey].(string)
 	if !hasKey {
 		return "", errors.New("Entiity does not seem to be a stop")
 	}
@@ -73,144 +116,140 @@ func stopNameFromMap(m map[string]interface{}) (string, error) {
 	return key, nil
 }
 
-func answer(w http.ResponseWriter, mesg string) {
+// optionalStopName returns the stop name of the parameter or an empty
+// name when the parameter is missing
+func optionalStopName(parameters map[string]interface{}, key string) (string, error) {
+	stopMap, hasStop := parameters[key].(map[string]interface{})
+	if !hasStop {
+		return "", nil
+	}
+	return stopNameFromMap(stopMap)
+}
 
-	resp := fullFillementResponse{Text: mesg}
-	w.Header().Set("Content-Type", "application/json; charset=utf8")
-	json.NewEncoder(w).Encode(&resp)
+// departureCountFrom returns the number of departures of the parameter or
+// zero when the parameter is missing. Dialogflow sends the unfilled
+// parameters as empty strings.
+func departureCountFrom(parameters map[string]interface{}, key string) (int, error) {
+	switch value := parameters[key].(type) {
+	case nil:
+		return 0, nil
+	case string:
+		if value == "" {
+			return 0, nil
+		}
+	case float64:
+		if value >= 0 && value == math.Trunc(value) {
+			return int(value), nil
+		}
+	}
+	return 0, fmt.Errorf("unexpected number of departures %v", parameters[key])
 }
 
-func handleNextDepartureQuery(w http.ResponseWriter, f fullfillment) {
+func textResponse(mesg string) fullFillementResponse {
+	return textResponseWithContexts(mesg, nil)
+}
 
-	log.Printf("Next departure query...\n")
-	parameters := f.QueryResult.Parameters
+func textResponseWithContexts(mesg string, contexts []outputContext) fullFillementResponse {
+	return fullFillementResponse{Text: mesg, OutputContexts: contexts}
+}
 
-	// Get origin
-	stopOriginMap, hasOrigin := parameters[StopOriginKey].(map[string]interface{})
+// speechResponse tells an utterance of the assistant
+func speechResponse(speech assistant.Utterance) fullFillementResponse {
+	return fullFillementResponse{Text: speech.Text, SSML: speech.SSML}
+}
 
-	if !hasOrigin {
-		log.Printf("The origin information has not been provided by the bot\n")
-		answer(w, "Une erreur est survenue sur nos serveurs. Veuillez nous excuser pour ce contre-temps.")
-		return
+// errorResponse tells an error of the assistant, asking the user to
+// choose a stop when its name is ambiguous.
+func errorResponse(f fullfillment, err error) fullFillementResponse {
+	if ambiguous, isAmbiguous := err.(*assistant.AmbiguousStopError); isAmbiguous {
+		return stopQuestionResponse(f, ambiguous)
 	}
+	log.Printf("The query failed: %v\n", err)
+	return speechResponse(assistant.Speech(err, f.printer().Language()))
+}
+
+func respond(w http.ResponseWriter, resp fullFillementResponse) {
+	resp = resp.withSpeech()
+	w.Header().Set("Content-Type", "application/json; charset=utf8")
+	json.NewEncoder(w).Encode(&resp)
+}
+
+func (h *webhook) handleNextDepartureQuery(ctx context.Context, f fullfillment) fullFillementResponse {
+
+	parameters := f.QueryResult.Parameters
+	p := f.printer()
 
-	stopOriginName, err := stopNameFromMap(stopOriginMap)
+	// Get origin, the closest stop to the user is used when it is missing
+	stopOriginName, err := optionalStopName(parameters, StopOriginKey)
 	if err != nil {
 		log.Printf("The origin value has not been provided\n")
-		answer(w, "Une erreur est survenue sur nos serveurs. Veuillez nous excuser pour ce contre-temps.")
-		return
+		return textResponse(p.Sprintf(i18n.InternalError))
 	}
 
-	originStop, err := store.GetStopByName(stopOriginName)
+	// Get direction, the departures of all the directions are told when it is missing
+	stopDirectionName, err := optionalStopName(parameters, StopDirectionKey)
 	if err != nil {
-		log.Printf("The origin value has not been found in the index\n")
-		answer(w, "Une erreur est survenue sur nos serveurs. Veuillez nous excuser pour ce contre-temps.")
-		return
-	}
-
-	// Get direction
-	stopDirectionMap, hasDirection := parameters[StopDirectionKey].(map[string]interface{})
-	if !hasDirection {
-		log.Printf("The direction value has not been found in the index\n")
-		answer(w, "Une erreur est survenue sur nos serveurs. Veuillez nous excuser pour ce contre-temps.")
-		return
+		log.Printf("The direction value has not been provided\n")
+		return textResponse(p.Sprintf(i18n.InternalError))
 	}
 
-	stopDirectionName, err := stopNameFromMap(stopDirectionMap)
+	at, err := departureTimeFrom(parameters, time.Now())
 	if err != nil {
-		log.Printf("The direction value has not been provided\n")
-		answer(w, "Une erreur est survenue sur nos serveurs. Veuillez nous excuser pour ce contre-temps.")
-		return
+		log.Printf("The departure time is invalid: %v\n", err)
+		return textResponse(p.Sprintf(i18n.UnknownTime))
 	}
 
-	lineName, hasLine := parameters[LineNameKey].(string)
-	if !hasLine {
-		log.Printf("The line value has not been found in the index\n")
-		answer(w, "Une erreur est survenue sur nos serveurs. Veuillez nous excuser pour ce contre-temps.")
-		return
+	lineName, _ := parameters[LineNameKey].(string)
+	query := assistant.NextDepartureQuery{
+		Line:      lineName,
+		Origin:    stopOriginName,
+		Direction: stopDirectionName,
+		At:        at,
+		Language:  p.Language(),
 	}
 
-	// We look for the line in the system
-	line, err := store.GetLineByName(lineName)
-	if err != nil {
-		log.Printf("The line %s has not been found in the store: %v\n", lineName, err)
-		answer(w, fmt.Sprintf("Je n'arrive pas à identifier la ligne correspondant à %s dans mon système.", lineName))
-		return
+	if position, hasPosition := f.deviceLocation(); hasPosition {
+		query.Position = geo.Point{Lat: position.Latitude, Lng: position.Longitude}
 	}
 
-	// We ensure lines holds the start stop
-	isStartStopInLine := false
-	for _, name := range originStop.LinesShortName {
-
-		if line.ShortName == name {
-			isStartStopInLine = true
+	// Follow-ups skip the departures already announced
+	if query.Offset, err = departureCountFrom(parameters, DepartureOffsetKey); err != nil {
+		log.Printf("The departure offset is invalid: %v\n", err)
+		return textResponse(p.Sprintf(i18n.UnknownCount))
+	}
+	if value, hasAfter := parameters[departureAfterKey].(string); hasAfter {
+		after, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			log.Printf("The time of the last departure told is invalid: %v\n", err)
+			return textResponse(p.Sprintf(i18n.InternalError))
 		}
+		query.After = after
 	}
-
-	if !isStartStopInLine {
-		answer(w, fmt.Sprintf("La ligne %s ne semble pas s'arrêter à l'arrêt %s", line.ShortName, originStop.Name))
-		return
+	if query.Count, err = departureCountFrom(parameters, DepartureCountKey); err != nil {
+		log.Printf("The departure count is invalid: %v\n", err)
+		return textResponse(p.Sprintf(i18n.UnknownCount))
 	}
-	// Then we try to find a routes for the corresponding destination
-	routes, err := store.GetRoutesForLineID(line.ID)
+
+	answer, err := h.core.NextDepartures(ctx, query)
 	if err != nil {
-		log.Printf("The routes has not been found in the store: %v\n", err)
-		answer(w, fmt.Sprintf("Je ne peux fournir des informations concernant la ligne %s pour le moment.", line.Name))
-		return
+		return errorResponse(f, err)
 	}
 
-	for _, route := range routes {
-
-		if route.CityDestinationStopName == stopDirectionName {
-			answerNextSchedule(w, originStop, route, line)
-			return
+	resp := speechResponse(answer.Utterance)
+	if len(answer.Rows) > 0 {
+		resp.FulfillmentMessages = []message{
+			{Text: &textMessage{Text: []string{answer.Text}}},
+			{Card: &card{Title: answer.Title, Subtitle: strings.Join(answer.Rows, "\n")}},
 		}
 	}
 
-	answer(w, fmt.Sprintf("Aucune route en direction de %s n'a été trouvée pour la ligne %s", stopDirectionName, line.Name))
-
-}
-
-func getNextDeparture(stop tlgo.Stop, route tlgo.Route, lineID string) ([]tlgo.Journey, error) {
-	return tlClient.ListStopDepartures(stop.ID, lineID, time.Now(), route.Wayback)
-}
-
-func answerNextSchedule(w http.ResponseWriter, stop tlgo.Stop, route tlgo.Route, line tlgo.Line) {
-
-	log.Printf("Asking next departure from %s to %s via %s \n", stop.Name, route.CityDestinationStopName, line.ShortName)
-
-	departures, err := getNextDeparture(stop, route, line.ID)
-
-	if err != nil {
-		log.Println("TLAPI get schedules error:", err)
-		answer(w, "Une erreur est survenue sur nos serveurs. Veuillez nous excuser pour ce contre-temps.")
-		return
-	}
-
-	if len(departures) < 1 {
-		msg := fmt.Sprintf("Aucun départ n'a été trouvé sur la ligne %s en direction de %s", line.ShortName, route.CityDestination)
-		answer(w, msg)
+	// The boards mixing several lines can not be followed up
+	if answer.Mode != assistant.BoardDepartures {
+		resp.OutputContexts = []outputContext{departureQueryContextFor(f, answer)}
 	}
 
-	msg := fmt.Sprintf("Le prochain bus %s en direction de %s partira ", line.ShortName, route.CityDestination)
-
-	departure := departures[0]
-
-	log.Printf("Asnwer before rounding is: %s\n", departure.WaitingTime)
-	var waiting string
-	if departure.WaitingTime.Seconds() < 60 {
-		waiting = fmt.Sprintf("dans %d secondes environ", int(departure.WaitingTime.Seconds()))
-	} else if departure.WaitingTime.Hours() < 1.0 {
-
-		seconds := int(departure.WaitingTime.Seconds())
-		minutes := seconds / 60
-		waiting = fmt.Sprintf("dans %d minutes et %d secondes environ", minutes, seconds%60)
-
-	} else {
-		waiting = "dans plus d'une heure."
+	if f.hasScreen() {
+		resp = resp.withGooglePayload(h.googleDeparturesPayload(p, answer))
 	}
-
-	msg += waiting + fmt.Sprintf(" depuis %s", stop.Name)
-
-	answer(w, msg)
+	return resp
 }
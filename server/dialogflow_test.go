@@ -0,0 +1,154 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gophersch/tlgo"
+	"github.com/yageek/tl-ai/i18n"
+)
+
+// stalledProvider never answers before the deadline of the request
+type stalledProvider struct{}
+
+func (stalledProvider) StopDepartures(ctx context.Context, stopID string, lineID string, at time.Time, wayback bool) ([]tlgo.Journey, error) {
+	<-ctx.Done()
+	return nil, ctx.Err()
+}
+
+// dialogFlowExchange sends the request to the webhook and returns its response
+func dialogFlowExchange(t *testing.T, hook *webhook, req fullfillment) fullFillementResponse {
+	t.Helper()
+
+	b, err := json.Marshal(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	r := httptest.NewRequest(http.MethodPost, "/dialogflow_interactions", bytes.NewReader(b))
+	r.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	hook.dialogFlowHandler(w, r)
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", w.Code)
+	}
+
+	resp := fullFillementResponse{}
+	if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+		t.Fatal(err)
+	}
+	return resp
+}
+
+// dialogFlowRequest asks the intent in French
+func dialogFlowRequest(intentName string, parameters map[string]interface{}) fullfillment {
+	return fullfillment{
+		Session: "projects/tl/agent/sessions/session",
+		QueryResult: queryResult{
+			LanguageCode: "fr",
+			Intent:       intent{DisplayName: intentName},
+			Parameters:   parameters,
+		},
+	}
+}
+
+func nextDepartureRequest() fullfillment {
+	return dialogFlowRequest(dialogFlowNextDepartureIntent, map[string]interface{}{
+		LineNameKey:      "2",
+		StopOriginKey:    map[string]interface{}{StopNameKey: "Flon"},
+		StopDirectionKey: map[string]interface{}{StopNameKey: "Gare"},
+	})
+}
+
+func TestDialogFlowReplaysFixtures(t *testing.T) {
+
+	resp := dialogFlowExchange(t, newTestWebhook(t, nil, time.Second), nextDepartureRequest())
+
+	want := i18n.NewPrinter(i18n.French).Sprintf(i18n.NextDeparture, "2", "Gare", "", "dans 2 minutes", "Flon")
+	if resp.Text != want {
+		t.Errorf("expected %q, got %q", want, resp.Text)
+	}
+	if len(resp.OutputContexts) != 1 {
+		t.Errorf("expected the departure query to be remembered, got %+v", resp.OutputContexts)
+	}
+}
+
+func TestDialogFlowLanguage(t *testing.T) {
+
+	hook := newTestWebhook(t, nil, time.Second)
+
+	tests := []struct {
+		code string
+		want string
+	}{
+		{"de-CH", "in 2 Minuten"},
+		{"it", "tra 2 minuti"},
+		{"en-GB", "in 2 minutes"},
+		{"es", "dans 2 minutes"},
+	}
+
+	for _, test := range tests {
+		req := nextDepartureRequest()
+		req.QueryResult.LanguageCode = test.code
+
+		resp := dialogFlowExchange(t, hook, req)
+		language := i18n.ParseLanguage(test.code)
+		want := i18n.NewPrinter(language).Sprintf(i18n.NextDeparture, "2", "Gare", "", test.want, "Flon")
+		if resp.Text != want {
+			t.Errorf("%s: expected %q, got %q", test.code, want, resp.Text)
+		}
+	}
+}
+
+func TestDialogFlowStalledDepartures(t *testing.T) {
+
+	const timeout = 50 * time.Millisecond
+	hook := newTestWebhook(t, stalledProvider{}, timeout)
+
+	start := time.Now()
+	resp := dialogFlowExchange(t, hook, nextDepartureRequest())
+	if elapsed := time.Since(start); elapsed > 10*timeout {
+		t.Errorf("the answer took %s with a timeout of %s", elapsed, timeout)
+	}
+
+	want := i18n.NewPrinter(i18n.French).Sprintf(i18n.DeparturesUnavailable)
+	if resp.Text != want {
+		t.Errorf("expected %q, got %q", want, resp.Text)
+	}
+}
+
+func TestDialogFlowDepartureCount(t *testing.T) {
+
+	hook := newTestWebhook(t, nil, time.Second)
+	unknown := i18n.NewPrinter(i18n.French).Sprintf(i18n.UnknownCount)
+
+	tests := []struct {
+		name       string
+		parameters map[string]interface{}
+		invalid    bool
+	}{
+		{"unfilled", map[string]interface{}{DepartureCountKey: "", DepartureOffsetKey: ""}, false},
+		{"two", map[string]interface{}{DepartureCountKey: 2.0}, false},
+		{"negative count", map[string]interface{}{DepartureCountKey: -1.0}, true},
+		{"fractional count", map[string]interface{}{DepartureCountKey: 1.5}, true},
+		{"negative offset", map[string]interface{}{DepartureOffsetKey: -2.0}, true},
+		{"spoken offset", map[string]interface{}{DepartureOffsetKey: "deux"}, true},
+	}
+
+	for _, test := range tests {
+		req := nextDepartureRequest()
+		for key, value := range test.parameters {
+			req.QueryResult.Parameters[key] = value
+		}
+
+		resp := dialogFlowExchange(t, hook, req)
+		if invalid := resp.Text == unknown; invalid != test.invalid {
+			t.Errorf("%s: expected the count to be invalid: %v, got %q", test.name, test.invalid, resp.Text)
+		}
+	}
+}
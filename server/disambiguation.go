@@ -0,0 +1,112 @@
+package main
+
+import (
+	"context"
+	"log"
+
+	"github.com/yageek/tl-ai/assistant"
+	"github.com/yageek/tl-ai/i18n"
+)
+
+const (
+	dialogFlowStopDisambiguationIntent = "StopDisambiguation"
+	stopDisambiguationContext          = "stop-disambiguation"
+	disambiguationContextLifespan      = 2
+	StopNameKey                        = "stop-name"
+	OrdinalKey                         = "ordinal"
+
+	pendingIntentKey     = "pending-intent"
+	pendingKeyKey        = "pending-key"
+	pendingParametersKey = "pending-parameters"
+	candidatesKey        = "candidates"
+)
+
+// stopRoleKeys are the parameters holding the stops of the queries
+var stopRoleKeys = map[assistant.StopRole]string{
+	assistant.OriginStop:      StopOriginKey,
+	assistant.DirectionStop:   StopDirectionKey,
+	assistant.DestinationStop: StopDestinationKey,
+}
+
+// stopQuestionResponse asks the user to choose one of the stops matching
+// an ambiguous name and keeps the pending query to resume it.
+func stopQuestionResponse(f fullfillment, ambiguous *assistant.AmbiguousStopError) fullFillementResponse {
+
+	ctx := outputContext{
+		Name:          f.contextName(stopDisambiguationContext),
+		LifespanCount: disambiguationContextLifespan,
+		Parameters: map[string]interface{}{
+			pendingIntentKey:     f.QueryResult.Intent.DisplayName,
+			pendingKeyKey:        stopRoleKeys[ambiguous.Role],
+			pendingParametersKey: f.QueryResult.Parameters,
+			candidatesKey:        ambiguous.Candidates,
+		},
+	}
+
+	p := f.printer()
+	resp := textResponseWithContexts(ambiguous.Question(p.Language()), []outputContext{ctx})
+	if f.hasScreen() {
+		resp = resp.withGooglePayload(googleStopListPayload(p, ambiguous.Candidates))
+	}
+	return resp
+}
+
+// handleStopDisambiguation resumes the query waiting for the user to choose a stop
+func (h *webhook) handleStopDisambiguation(ctx context.Context, f fullfillment) fullFillementResponse {
+
+	log.Printf("Stop disambiguation...\n")
+	p := f.printer()
+
+	pending, hasContext := f.QueryResult.context(stopDisambiguationContext)
+	if !hasContext {
+		log.Printf("No pending disambiguation for the session\n")
+		return textResponse(p.Sprintf(i18n.UnknownStop))
+	}
+
+	pendingIntent, _ := pending.Parameters[pendingIntentKey].(string)
+	pendingKey, _ := pending.Parameters[pendingKeyKey].(string)
+	pendingParameters, _ := pending.Parameters[pendingParametersKey].(map[string]interface{})
+	rawCandidates, _ := pending.Parameters[candidatesKey].([]interface{})
+
+	candidates := make([]string, 0, len(rawCandidates))
+	for _, candidate := range rawCandidates {
+		if name, isString := candidate.(string); isString {
+			candidates = append(candidates, name)
+		}
+	}
+
+	if pendingIntent == "" || pendingKey == "" || pendingParameters == nil || len(candidates) < 2 {
+		log.Printf("The disambiguation context is invalid: %v\n", pending.Parameters)
+		return textResponse(p.Sprintf(i18n.InternalError))
+	}
+
+	ordinal, _ := f.QueryResult.Parameters[OrdinalKey].(float64)
+	name, _ := f.QueryResult.Parameters[StopNameKey].(string)
+	if stopMap, isMap := f.QueryResult.Parameters[StopNameKey].(map[string]interface{}); isMap {
+		name, _ = stopMap[StopNameKey].(string)
+	}
+
+	// The stops chosen in a list are identified by their name
+	if option, hasOption := f.selectedOption(); hasOption {
+		name = option
+	}
+
+	chosen, hasChosen := h.core.ChooseStop(candidates, int(ordinal), name)
+	if !hasChosen {
+		pending.LifespanCount = disambiguationContextLifespan
+		question := (&assistant.AmbiguousStopError{Candidates: candidates}).Question(p.Language())
+		return textResponseWithContexts(p.Sprintf(i18n.NotUnderstoodQuestion, question), []outputContext{pending})
+	}
+
+	// Replay the pending query with the chosen stop
+	pendingParameters[pendingKey] = map[string]interface{}{StopNameKey: chosen}
+	f.QueryResult.Parameters = pendingParameters
+	f.QueryResult.Intent.DisplayName = pendingIntent
+
+	resp, err := h.dispatchIntent(ctx, f)
+	if err != nil {
+		log.Printf("The pending intent %s can not be resumed: %v\n", pendingIntent, err)
+		return textResponse(p.Sprintf(i18n.InternalError))
+	}
+	return resp
+}
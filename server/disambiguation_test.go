@@ -0,0 +1,73 @@
+package main
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+// roundTrip returns the contexts as Dialogflow sends them back
+func roundTrip(t *testing.T, contexts []outputContext) []outputContext {
+	t.Helper()
+
+	b, err := json.Marshal(contexts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sent := []outputContext{}
+	if err := json.Unmarshal(b, &sent); err != nil {
+		t.Fatal(err)
+	}
+	return sent
+}
+
+func TestDialogFlowStopDisambiguation(t *testing.T) {
+
+	hook := newTestWebhook(t, nil, time.Second)
+
+	resp := dialogFlowExchange(t, hook, dialogFlowRequest(dialogFlowJourneyIntent, map[string]interface{}{
+		StopOriginKey:      map[string]interface{}{StopNameKey: "Bessières"},
+		StopDestinationKey: map[string]interface{}{StopNameKey: "Gare"},
+	}))
+
+	const question = "Voulez-vous dire Bessières Sud ou Bessières Nord ?"
+	if resp.Text != question {
+		t.Fatalf("expected %q, got %q", question, resp.Text)
+	}
+	if len(resp.OutputContexts) != 1 {
+		t.Fatalf("expected the pending query to be remembered, got %+v", resp.OutputContexts)
+	}
+	pending := roundTrip(t, resp.OutputContexts)
+
+	// An answer not matching any candidate asks again
+	req := dialogFlowRequest(dialogFlowStopDisambiguationIntent, map[string]interface{}{OrdinalKey: 3.0})
+	req.QueryResult.OutputContexts = pending
+	resp = dialogFlowExchange(t, hook, req)
+	if want := "Je n'ai pas compris. " + question; resp.Text != want {
+		t.Errorf("expected %q, got %q", want, resp.Text)
+	}
+
+	tests := []struct {
+		name       string
+		parameters map[string]interface{}
+		want       string
+	}{
+		{"ordinal", map[string]interface{}{OrdinalKey: 2.0}, "Depuis Bessières Nord, prenez la ligne 2 en direction de Gare jusqu'à Gare."},
+		{"name", map[string]interface{}{StopNameKey: "sud"}, "Depuis Bessières Sud, prenez la ligne 2 en direction de Gare jusqu'à Gare."},
+	}
+
+	for _, test := range tests {
+		req := dialogFlowRequest(dialogFlowStopDisambiguationIntent, test.parameters)
+		req.QueryResult.OutputContexts = pending
+		resp := dialogFlowExchange(t, hook, req)
+		if resp.Text != test.want {
+			t.Errorf("%s: expected %q, got %q", test.name, test.want, resp.Text)
+		}
+	}
+
+	// Without pending query, the stop is not known
+	resp = dialogFlowExchange(t, hook, dialogFlowRequest(dialogFlowStopDisambiguationIntent, map[string]interface{}{OrdinalKey: 1.0}))
+	if want := "Je n'ai pas compris de quel arrêt vous parlez."; resp.Text != want {
+		t.Errorf("expected %q, got %q", want, resp.Text)
+	}
+}
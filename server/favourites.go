@@ -0,0 +1,86 @@
+package main
+
+import (
+	"context"
+	"log"
+
+	"github.com/yageek/tl-ai/favourites"
+	"github.com/yageek/tl-ai/i18n"
+)
+
+const (
+	dialogFlowSaveFavouriteIntent = "SaveFavourite"
+	dialogFlowMyBusIntent         = "MyBus"
+)
+
+// handleSaveFavourite answers "enregistre mon trajet" by saving the last
+// departure query of the session as the favourite of the user.
+func (h *webhook) handleSaveFavourite(f fullfillment) fullFillementResponse {
+
+	log.Printf("Save favourite...\n")
+	p := f.printer()
+
+	userID := f.userID()
+	if userID == "" {
+		return textResponse(p.Sprintf(i18n.AnonymousUser))
+	}
+
+	query, hasContext := f.QueryResult.context(departureQueryContext)
+	if !hasContext {
+		log.Printf("No departure query to save for the session\n")
+		return textResponse(p.Sprintf(i18n.NoFavouriteQuery))
+	}
+
+	line, _ := query.Parameters[LineNameKey].(string)
+	origin, _ := optionalStopName(query.Parameters, StopOriginKey)
+	direction, _ := optionalStopName(query.Parameters, StopDirectionKey)
+	if line == "" || origin == "" || direction == "" {
+		log.Printf("The departure query context is incomplete: %v\n", query.Parameters)
+		return textResponse(p.Sprintf(i18n.NoFavouriteQuery))
+	}
+
+	favourite := favourites.Favourite{Line: line, Origin: origin, Direction: direction}
+	if err := h.favourites.Put(userID, favourite); err != nil {
+		log.Printf("The favourite can not be saved: %v\n", err)
+		return textResponse(p.Sprintf(i18n.InternalError))
+	}
+
+	return textResponse(p.Sprintf(i18n.FavouriteSaved, line, origin, direction))
+}
+
+// handleMyBus answers "mon bus" by replaying the favourite of the user
+func (h *webhook) handleMyBus(ctx context.Context, f fullfillment) fullFillementResponse {
+
+	log.Printf("My bus...\n")
+	p := f.printer()
+
+	userID := f.userID()
+	if userID == "" {
+		return textResponse(p.Sprintf(i18n.AnonymousUser))
+	}
+
+	favourite, err := h.favourites.Get(userID)
+	if err == favourites.ErrNotFound {
+		return textResponse(p.Sprintf(i18n.NoFavourite))
+	} else if err != nil {
+		log.Printf("The favourite can not be read: %v\n", err)
+		return textResponse(p.Sprintf(i18n.InternalError))
+	}
+
+	parameters := map[string]interface{}{
+		LineNameKey:      favourite.Line,
+		StopOriginKey:    map[string]interface{}{StopNameKey: favourite.Origin},
+		StopDirectionKey: map[string]interface{}{StopNameKey: favourite.Direction},
+	}
+
+	// "mon bus de 8h" and "mes 3 prochains bus" keep their time and count
+	for _, key := range []string{DepartureTimeKey, DepartureCountKey} {
+		if value, hasValue := f.QueryResult.Parameters[key]; hasValue {
+			parameters[key] = value
+		}
+	}
+
+	f.QueryResult.Parameters = parameters
+	f.QueryResult.Intent.DisplayName = dialogFlowNextDepartureIntent
+	return h.handleNextDepartureQuery(ctx, f)
+}
@@ -0,0 +1,65 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"github.com/yageek/tl-ai/favourites"
+	"github.com/yageek/tl-ai/i18n"
+)
+
+// asUser sends the request on behalf of the user
+func asUser(f fullfillment, userID string) fullfillment {
+	f.OriginalRequest.Payload.User.UserID = userID
+	return f
+}
+
+func TestDialogFlowFavourite(t *testing.T) {
+
+	hook := newTestWebhook(t, nil, time.Second)
+	p := i18n.NewPrinter(i18n.French)
+
+	// Nothing to replay yet
+	resp := dialogFlowExchange(t, hook, asUser(dialogFlowRequest(dialogFlowMyBusIntent, map[string]interface{}{}), "alice"))
+	if want := p.Sprintf(i18n.NoFavourite); resp.Text != want {
+		t.Errorf("expected %q, got %q", want, resp.Text)
+	}
+
+	// Nothing to save without a departure query
+	resp = dialogFlowExchange(t, hook, asUser(dialogFlowRequest(dialogFlowSaveFavouriteIntent, map[string]interface{}{}), "alice"))
+	if want := p.Sprintf(i18n.NoFavouriteQuery); resp.Text != want {
+		t.Errorf("expected %q, got %q", want, resp.Text)
+	}
+
+	// The anonymous users can not have favourites
+	resp = dialogFlowExchange(t, hook, dialogFlowRequest(dialogFlowMyBusIntent, map[string]interface{}{}))
+	if want := p.Sprintf(i18n.AnonymousUser); resp.Text != want {
+		t.Errorf("expected %q, got %q", want, resp.Text)
+	}
+
+	departures := dialogFlowExchange(t, hook, asUser(nextDepartureRequest(), "alice"))
+
+	save := asUser(dialogFlowRequest(dialogFlowSaveFavouriteIntent, map[string]interface{}{}), "alice")
+	save.QueryResult.OutputContexts = roundTrip(t, departures.OutputContexts)
+	resp = dialogFlowExchange(t, hook, save)
+	if want := p.Sprintf(i18n.FavouriteSaved, "2", "Flon", "Gare"); resp.Text != want {
+		t.Errorf("expected %q, got %q", want, resp.Text)
+	}
+
+	want := favourites.Favourite{Line: "2", Origin: "Flon", Direction: "Gare"}
+	if favourite, err := hook.favourites.Get("alice"); err != nil || favourite != want {
+		t.Errorf("expected %+v, got %+v (%v)", want, favourite, err)
+	}
+
+	// In a new session, the favourite is replayed
+	resp = dialogFlowExchange(t, hook, asUser(dialogFlowRequest(dialogFlowMyBusIntent, map[string]interface{}{}), "alice"))
+	if resp.Text != departures.Text {
+		t.Errorf("expected %q, got %q", departures.Text, resp.Text)
+	}
+
+	// The favourites are kept by user
+	resp = dialogFlowExchange(t, hook, asUser(dialogFlowRequest(dialogFlowMyBusIntent, map[string]interface{}{}), "bob"))
+	if want := p.Sprintf(i18n.NoFavourite); resp.Text != want {
+		t.Errorf("expected %q, got %q", want, resp.Text)
+	}
+}
@@ -0,0 +1,91 @@
+package main
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/yageek/tl-ai/assistant"
+	"github.com/yageek/tl-ai/i18n"
+)
+
+const (
+	dialogFlowNextDepartureFollowingIntent = "NextDepartureFollowing"
+	dialogFlowNextDepartureReverseIntent   = "NextDepartureOtherDirection"
+	departureQueryContext                  = "departure-query"
+	departureQueryContextLifespan          = 5
+	DepartureOffsetKey                     = "departure-offset"
+	departureAfterKey                      = "departure-after"
+	routeOriginKey                         = "route-origin"
+)
+
+// departureQueryContextFor remembers the resolved departure query and
+// the departures already told so the follow-ups of the session can reuse it.
+func departureQueryContextFor(f fullfillment, answer assistant.NextDepartureAnswer) outputContext {
+	ctx := outputContext{
+		Name:          f.contextName(departureQueryContext),
+		LifespanCount: departureQueryContextLifespan,
+		Parameters: map[string]interface{}{
+			LineNameKey:      answer.Line.ShortName,
+			StopOriginKey:    map[string]interface{}{StopNameKey: answer.Stop.Name},
+			StopDirectionKey: map[string]interface{}{StopNameKey: answer.Route.CityDestinationStopName},
+			routeOriginKey:   answer.Route.CityOriginStopName,
+		},
+	}
+
+	// The following departures leave after the last one told
+	after := answer.After
+	if told := len(answer.Departures); told > 0 {
+		after = answer.Departures[told-1].Time
+	}
+	if !after.IsZero() {
+		ctx.Parameters[departureAfterKey] = after.Format(time.RFC3339)
+	}
+
+	// Follow-ups of a query for later stay at the asked time
+	if answer.Later {
+		ctx.Parameters[DepartureTimeKey] = answer.At.Format(time.RFC3339)
+	}
+	return ctx
+}
+
+// handleNextDepartureFollowUp answers "et le suivant ?" and "et dans
+// l'autre sens ?" from the last departure query of the session.
+func (h *webhook) handleNextDepartureFollowUp(ctx context.Context, f fullfillment) fullFillementResponse {
+
+	log.Printf("Next departure follow-up...\n")
+
+	previous, hasContext := f.QueryResult.context(departureQueryContext)
+	if !hasContext {
+		log.Printf("No previous departure query for the session\n")
+		return textResponse(f.printer().Sprintf(i18n.UnknownBus))
+	}
+
+	parameters := map[string]interface{}{
+		LineNameKey:      previous.Parameters[LineNameKey],
+		StopOriginKey:    previous.Parameters[StopOriginKey],
+		StopDirectionKey: previous.Parameters[StopDirectionKey],
+	}
+	if at, hasTime := previous.Parameters[DepartureTimeKey]; hasTime {
+		parameters[DepartureTimeKey] = at
+	}
+
+	// "et les 3 suivants ?" may ask for several departures
+	if count, hasCount := f.QueryResult.Parameters[DepartureCountKey].(float64); hasCount {
+		parameters[DepartureCountKey] = count
+	}
+
+	switch f.QueryResult.Intent.DisplayName {
+	case dialogFlowNextDepartureFollowingIntent:
+		if after, hasAfter := previous.Parameters[departureAfterKey]; hasAfter {
+			parameters[departureAfterKey] = after
+		}
+	case dialogFlowNextDepartureReverseIntent:
+		routeOrigin, _ := previous.Parameters[routeOriginKey].(string)
+		parameters[StopDirectionKey] = map[string]interface{}{StopNameKey: routeOrigin}
+	}
+
+	f.QueryResult.Parameters = parameters
+	f.QueryResult.Intent.DisplayName = dialogFlowNextDepartureIntent
+	return h.handleNextDepartureQuery(ctx, f)
+}
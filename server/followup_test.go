@@ -0,0 +1,72 @@
+package main
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gophersch/tlgo"
+	"github.com/yageek/tl-ai/assistant"
+)
+
+func TestDepartureQueryContext(t *testing.T) {
+
+	last := time.Date(2026, 10, 19, 7, 59, 0, 0, time.UTC)
+	answer := assistant.NextDepartureAnswer{
+		Stop:       tlgo.Stop{Name: "Flon"},
+		Line:       tlgo.Line{ShortName: "2"},
+		Route:      tlgo.Route{CityOriginStopName: "Ouchy", CityDestinationStopName: "Gare"},
+		Departures: []assistant.Departure{{Time: last.Add(-7 * time.Minute)}, {Time: last}},
+	}
+
+	f := dialogFlowRequest(dialogFlowNextDepartureIntent, map[string]interface{}{})
+	ctx := roundTrip(t, []outputContext{departureQueryContextFor(f, answer)})[0]
+
+	if want := f.Session + "/contexts/" + departureQueryContext; ctx.Name != want {
+		t.Errorf("expected the context %q, got %q", want, ctx.Name)
+	}
+
+	// The follow-ups find the parameters of the query as Dialogflow sends them back
+	f.QueryResult.OutputContexts = []outputContext{ctx}
+	remembered, hasContext := f.QueryResult.context(departureQueryContext)
+	if !hasContext {
+		t.Fatal("expected the departure query to be found")
+	}
+
+	origin, _ := remembered.Parameters[StopOriginKey].(map[string]interface{})
+	direction, _ := remembered.Parameters[StopDirectionKey].(map[string]interface{})
+	if remembered.Parameters[LineNameKey] != "2" || origin[StopNameKey] != "Flon" || direction[StopNameKey] != "Gare" {
+		t.Errorf("expected line 2 from Flon to Gare, got %v", remembered.Parameters)
+	}
+	if remembered.Parameters[routeOriginKey] != "Ouchy" || remembered.Parameters[departureAfterKey] != last.Format(time.RFC3339) {
+		t.Errorf("expected the departures told until %s on the route from Ouchy, got %v", last, remembered.Parameters)
+	}
+}
+
+func TestDialogFlowFollowingDeparture(t *testing.T) {
+
+	hook := newTestWebhook(t, nil, time.Second)
+	resp := dialogFlowExchange(t, hook, nextDepartureRequest())
+
+	// The follow-up is sent with the contexts of the answer
+	f := dialogFlowRequest(dialogFlowNextDepartureFollowingIntent, map[string]interface{}{})
+	f.QueryResult.OutputContexts = resp.OutputContexts
+	resp = dialogFlowExchange(t, hook, f)
+
+	if want := "Le bus 2 suivant en direction de Gare partira dans 9 minutes depuis Flon"; !strings.HasPrefix(resp.Text, want) {
+		t.Errorf("expected %q, got %q", want, resp.Text)
+	}
+}
+
+func TestDialogFlowFollowUpWithoutQuery(t *testing.T) {
+
+	hook := newTestWebhook(t, nil, time.Second)
+
+	const want = "De quel bus parlez-vous ? Précisez la ligne, l'arrêt de départ et la direction."
+	for _, intentName := range []string{dialogFlowNextDepartureFollowingIntent, dialogFlowNextDepartureReverseIntent} {
+		resp := dialogFlowExchange(t, hook, dialogFlowRequest(intentName, map[string]interface{}{}))
+		if resp.Text != want {
+			t.Errorf("%s: expected %q, got %q", intentName, want, resp.Text)
+		}
+	}
+}
@@ -0,0 +1,205 @@
+package main
+
+import (
+	"strings"
+
+	"github.com/yageek/tl-ai/assistant"
+	"github.com/yageek/tl-ai/i18n"
+)
+
+// Models of the Actions on Google rich responses, sent in the google
+// payload of the Dialogflow responses.
+// See https://developers.google.com/assistant/conversational/df-asdk/rich-responses
+
+const (
+	googlePayloadKey       = "google"
+	screenOutputCapability = "actions.capability.SCREEN_OUTPUT"
+	googleOptionIntent     = "actions.intent.OPTION"
+	googleOptionArgument   = "OPTION"
+	googleOptionValueSpec  = "type.googleapis.com/google.actions.v2.OptionValueSpec"
+)
+
+type googlePayload struct {
+	ExpectUserResponse bool                `json:"expectUserResponse"`
+	RichResponse       googleRichResponse  `json:"richResponse"`
+	SystemIntent       *googleSystemIntent `json:"systemIntent,omitempty"`
+}
+
+type googleRichResponse struct {
+	Items       []googleItem       `json:"items"`
+	Suggestions []googleSuggestion `json:"suggestions,omitempty"`
+}
+
+// googleItem is an item of a rich response. Only one of its fields is set.
+type googleItem struct {
+	SimpleResponse *simpleResponse  `json:"simpleResponse,omitempty"`
+	BasicCard      *googleBasicCard `json:"basicCard,omitempty"`
+	TableCard      *googleTableCard `json:"tableCard,omitempty"`
+}
+
+type googleBasicCard struct {
+	Title         string       `json:"title,omitempty"`
+	Subtitle      string       `json:"subtitle,omitempty"`
+	FormattedText string       `json:"formattedText,omitempty"`
+	Image         *googleImage `json:"image,omitempty"`
+}
+
+type googleTableCard struct {
+	Title            string                 `json:"title,omitempty"`
+	Subtitle         string                 `json:"subtitle,omitempty"`
+	Image            *googleImage           `json:"image,omitempty"`
+	ColumnProperties []googleColumnProperty `json:"columnProperties"`
+	Rows             []googleRow            `json:"rows"`
+}
+
+type googleColumnProperty struct {
+	Header string `json:"header"`
+}
+
+type googleRow struct {
+	Cells        []googleCell `json:"cells"`
+	DividerAfter bool         `json:"dividerAfter,omitempty"`
+}
+
+type googleCell struct {
+	Text string `json:"text"`
+}
+
+type googleImage struct {
+	URL               string `json:"url"`
+	AccessibilityText string `json:"accessibilityText"`
+}
+
+type googleSuggestion struct {
+	Title string `json:"title"`
+}
+
+type googleSystemIntent struct {
+	Intent string           `json:"intent"`
+	Data   googleOptionSpec `json:"data"`
+}
+
+type googleOptionSpec struct {
+	Type       string           `json:"@type"`
+	ListSelect googleListSelect `json:"listSelect"`
+}
+
+type googleListSelect struct {
+	Title string       `json:"title,omitempty"`
+	Items []googleList `json:"items"`
+}
+
+type googleList struct {
+	OptionInfo googleOptionInfo `json:"optionInfo"`
+	Title      string           `json:"title"`
+}
+
+type googleOptionInfo struct {
+	Key      string   `json:"key"`
+	Synonyms []string `json:"synonyms,omitempty"`
+}
+
+// hasScreen tells if the device of the user can show the rich responses
+func (f fullfillment) hasScreen() bool {
+	for _, capability := range f.OriginalRequest.Payload.Surface.Capabilities {
+		if capability.Name == screenOutputCapability {
+			return true
+		}
+	}
+	return false
+}
+
+// selectedOption returns the key of the list item chosen by the user
+func (f fullfillment) selectedOption() (string, bool) {
+	for _, input := range f.OriginalRequest.Payload.Inputs {
+		for _, argument := range input.Arguments {
+			if argument.Name == googleOptionArgument && argument.TextValue != "" {
+				return argument.TextValue, true
+			}
+		}
+	}
+	return "", false
+}
+
+// withGooglePayload sets the rich response shown on the screens
+func (r fullFillementResponse) withGooglePayload(payload googlePayload) fullFillementResponse {
+	speech := r.speech()
+	payload.RichResponse.Items = append([]googleItem{{
+		SimpleResponse: &simpleResponse{SSML: speech.Speak(), DisplayText: speech.Text},
+	}}, payload.RichResponse.Items...)
+
+	r.Payload = map[string]interface{}{googlePayloadKey: payload}
+	return r
+}
+
+// googleDeparturesPayload shows the departures of an answer: a basic card
+// for a single departure and a table otherwise, illustrated by the colour
+// of the line when it is known. Chips suggest the follow-ups.
+func (h *webhook) googleDeparturesPayload(p i18n.Printer, answer assistant.NextDepartureAnswer) googlePayload {
+
+	var image *googleImage
+	if answer.Mode != assistant.BoardDepartures {
+		if badge, hasBadge := h.lineBadgeURL(answer.Line.ShortName); hasBadge {
+			image = &googleImage{URL: badge, AccessibilityText: answer.Title}
+		}
+	}
+
+	payload := googlePayload{}
+	if len(answer.Departures) == 1 && answer.Mode != assistant.BoardDepartures {
+		payload.RichResponse.Items = []googleItem{{BasicCard: &googleBasicCard{
+			Title:         answer.Title,
+			Subtitle:      answer.Stop.Name,
+			FormattedText: strings.Join(answer.Rows, "  \n"),
+			Image:         image,
+		}}}
+	} else if len(answer.Departures) > 0 {
+		table := &googleTableCard{
+			Title:    answer.Title,
+			Subtitle: answer.Stop.Name,
+			Image:    image,
+			ColumnProperties: []googleColumnProperty{
+				{Header: p.Sprintf(i18n.LineColumn)},
+				{Header: p.Sprintf(i18n.DirectionColumn)},
+				{Header: p.Sprintf(i18n.DepartureColumn)},
+			},
+		}
+		for _, departure := range answer.Departures {
+			table.Rows = append(table.Rows, googleRow{Cells: []googleCell{
+				{Text: departure.Line.ShortName},
+				{Text: departure.Destination},
+				{Text: departure.Waiting},
+			}})
+		}
+		payload.RichResponse.Items = []googleItem{{TableCard: table}}
+	}
+
+	// The boards mixing several lines can not be followed up
+	if answer.Mode != assistant.BoardDepartures {
+		payload.ExpectUserResponse = true
+		payload.RichResponse.Suggestions = []googleSuggestion{
+			{Title: p.Sprintf(i18n.FollowingChip)},
+			{Title: p.Sprintf(i18n.OtherDirectionChip)},
+		}
+	}
+	return payload
+}
+
+// googleStopListPayload asks the user to choose a stop in a list
+func googleStopListPayload(p i18n.Printer, candidates []string) googlePayload {
+
+	list := googleListSelect{Title: p.Sprintf(i18n.StopListTitle)}
+	for _, candidate := range candidates {
+		list.Items = append(list.Items, googleList{
+			OptionInfo: googleOptionInfo{Key: candidate},
+			Title:      candidate,
+		})
+	}
+
+	return googlePayload{
+		ExpectUserResponse: true,
+		SystemIntent: &googleSystemIntent{
+			Intent: googleOptionIntent,
+			Data:   googleOptionSpec{Type: googleOptionValueSpec, ListSelect: list},
+		},
+	}
+}
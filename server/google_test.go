@@ -0,0 +1,168 @@
+package main
+
+import (
+	"encoding/json"
+	"image/color"
+	"testing"
+	"time"
+
+	"github.com/yageek/tl-ai/i18n"
+)
+
+// withScreen asks the request from a device with a screen
+func withScreen(f fullfillment) fullfillment {
+	f.OriginalRequest.Payload.Surface.Capabilities = []capability{{Name: "actions.capability.AUDIO_OUTPUT"}, {Name: screenOutputCapability}}
+	return f
+}
+
+// googleResponse returns the rich response of the answer
+func googleResponse(t *testing.T, resp fullFillementResponse) (googlePayload, bool) {
+	t.Helper()
+
+	raw, hasPayload := resp.Payload[googlePayloadKey]
+	if !hasPayload {
+		return googlePayload{}, false
+	}
+
+	b, err := json.Marshal(raw)
+	if err != nil {
+		t.Fatal(err)
+	}
+	payload := googlePayload{}
+	if err := json.Unmarshal(b, &payload); err != nil {
+		t.Fatal(err)
+	}
+	return payload, true
+}
+
+func TestGoogleDepartureCard(t *testing.T) {
+
+	hook := newTestWebhook(t, nil, time.Second)
+	hook.publicURL = "https://example.com"
+	hook.lineColours = map[string]color.RGBA{"2": {R: 0xe2, G: 0x00, B: 0x1a, A: 0xff}}
+	p := i18n.NewPrinter(i18n.French)
+
+	// The devices without screen only get the sentence
+	if _, hasPayload := googleResponse(t, dialogFlowExchange(t, hook, nextDepartureRequest())); hasPayload {
+		t.Errorf("expected no rich response without screen")
+	}
+
+	resp := dialogFlowExchange(t, hook, withScreen(nextDepartureRequest()))
+	payload, hasPayload := googleResponse(t, resp)
+	if !hasPayload {
+		t.Fatal("expected a rich response")
+	}
+
+	items := payload.RichResponse.Items
+	if len(items) != 2 || items[0].SimpleResponse == nil || items[1].BasicCard == nil {
+		t.Fatalf("expected a sentence and a card, got %+v", items)
+	}
+	if items[0].SimpleResponse.DisplayText != resp.Text {
+		t.Errorf("expected %q, got %q", resp.Text, items[0].SimpleResponse.DisplayText)
+	}
+
+	card := items[1].BasicCard
+	if want := p.Sprintf(i18n.RouteTitle, "2", "Gare"); card.Title != want || card.Subtitle != "Flon" {
+		t.Errorf("expected %q from Flon, got %q from %q", want, card.Title, card.Subtitle)
+	}
+	if card.Image == nil || card.Image.URL != "https://example.com/lines/2/badge.png" {
+		t.Errorf("expected the badge of the line, got %+v", card.Image)
+	}
+
+	if !payload.ExpectUserResponse || len(payload.RichResponse.Suggestions) != 2 {
+		t.Errorf("expected the follow-ups to be suggested, got %+v", payload.RichResponse.Suggestions)
+	}
+}
+
+func TestGoogleDepartureTable(t *testing.T) {
+
+	hook := newTestWebhook(t, nil, time.Second)
+	p := i18n.NewPrinter(i18n.French)
+
+	tests := []struct {
+		name        string
+		parameters  map[string]interface{}
+		rows        [][]string
+		suggestions int
+	}{
+		{"route", map[string]interface{}{
+			LineNameKey:       "2",
+			StopOriginKey:     map[string]interface{}{StopNameKey: "Flon"},
+			StopDirectionKey:  map[string]interface{}{StopNameKey: "Gare"},
+			DepartureCountKey: 3.0,
+		}, [][]string{
+			{"2", "Gare", p.Sprintf(i18n.ShortMinutes, 2)},
+			{"2", "Gare", p.Sprintf(i18n.ShortMinutes, 9)},
+			{"2", "Gare", p.Sprintf(i18n.ShortMinutes, 17)},
+		}, 2},
+		// The boards can not be followed up
+		{"board", map[string]interface{}{
+			StopOriginKey: map[string]interface{}{StopNameKey: "Flon"},
+		}, [][]string{
+			{"2", "Gare", p.Sprintf(i18n.ShortMinutes, 2)},
+			{"2", "Ouchy", p.Sprintf(i18n.ShortMinutes, 4)},
+			{"2", "Gare", p.Sprintf(i18n.ShortMinutes, 9)},
+			{"2", "Ouchy", p.Sprintf(i18n.ShortMinutes, 12)},
+		}, 0},
+	}
+
+	for _, test := range tests {
+		resp := dialogFlowExchange(t, hook, withScreen(dialogFlowRequest(dialogFlowNextDepartureIntent, test.parameters)))
+		payload, _ := googleResponse(t, resp)
+
+		items := payload.RichResponse.Items
+		if len(items) != 2 || items[1].TableCard == nil {
+			t.Errorf("%s: expected a table, got %+v", test.name, items)
+			continue
+		}
+
+		table := items[1].TableCard
+		if len(table.ColumnProperties) != 3 || len(table.Rows) != len(test.rows) {
+			t.Errorf("%s: expected %d rows of 3 columns, got %+v", test.name, len(test.rows), table)
+			continue
+		}
+		for i, row := range table.Rows {
+			for j, cell := range row.Cells {
+				if cell.Text != test.rows[i][j] {
+					t.Errorf("%s: expected %q in row %d, got %q", test.name, test.rows[i][j], i, cell.Text)
+				}
+			}
+		}
+
+		if len(payload.RichResponse.Suggestions) != test.suggestions {
+			t.Errorf("%s: expected %d suggestions, got %+v", test.name, test.suggestions, payload.RichResponse.Suggestions)
+		}
+	}
+}
+
+func TestGoogleStopList(t *testing.T) {
+
+	hook := newTestWebhook(t, nil, 0)
+
+	resp := dialogFlowExchange(t, hook, withScreen(dialogFlowRequest(dialogFlowJourneyIntent, map[string]interface{}{
+		StopOriginKey:      map[string]interface{}{StopNameKey: "Bessières"},
+		StopDestinationKey: map[string]interface{}{StopNameKey: "Gare"},
+	})))
+
+	payload, _ := googleResponse(t, resp)
+	if payload.SystemIntent == nil || payload.SystemIntent.Intent != googleOptionIntent {
+		t.Fatalf("expected a list selection, got %+v", payload)
+	}
+	items := payload.SystemIntent.Data.ListSelect.Items
+	if len(items) != 2 || items[0].OptionInfo.Key != "Bessières Sud" || items[1].OptionInfo.Key != "Bessières Nord" {
+		t.Fatalf("expected the Bessières stops, got %+v", items)
+	}
+
+	// The stop chosen in the list answers the question
+	req := withScreen(dialogFlowRequest(dialogFlowStopDisambiguationIntent, map[string]interface{}{}))
+	req.QueryResult.OutputContexts = roundTrip(t, resp.OutputContexts)
+	req.OriginalRequest.Payload.Inputs = []input{{
+		Intent:    googleOptionIntent,
+		Arguments: []argument{{Name: googleOptionArgument, TextValue: items[1].OptionInfo.Key}},
+	}}
+
+	resp = dialogFlowExchange(t, hook, req)
+	if want := "Depuis Bessières Nord, prenez la ligne 2 en direction de Gare jusqu'à Gare."; resp.Text != want {
+		t.Errorf("expected %q, got %q", want, resp.Text)
+	}
+}
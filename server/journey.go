@@ -0,0 +1,50 @@
+package main
+
+import (
+	"log"
+
+	"github.com/yageek/tl-ai/assistant"
+	"github.com/yageek/tl-ai/i18n"
+)
+
+func (h *webhook) handleJourneyQuery(f fullfillment) fullFillementResponse {
+
+	parameters := f.QueryResult.Parameters
+	p := f.printer()
+
+	// Get origin
+	stopOriginMap, hasOrigin := parameters[StopOriginKey].(map[string]interface{})
+	if !hasOrigin {
+		log.Printf("The origin information has not been provided by the bot\n")
+		return textResponse(p.Sprintf(i18n.InternalError))
+	}
+
+	stopOriginName, err := stopNameFromMap(stopOriginMap)
+	if err != nil {
+		log.Printf("The origin value has not been provided\n")
+		return textResponse(p.Sprintf(i18n.InternalError))
+	}
+
+	// Get destination
+	stopDestinationMap, hasDestination := parameters[StopDestinationKey].(map[string]interface{})
+	if !hasDestination {
+		log.Printf("The destination information has not been provided by the bot\n")
+		return textResponse(p.Sprintf(i18n.InternalError))
+	}
+
+	stopDestinationName, err := stopNameFromMap(stopDestinationMap)
+	if err != nil {
+		log.Printf("The destination value has not been provided\n")
+		return textResponse(p.Sprintf(i18n.InternalError))
+	}
+
+	answer, err := h.core.Journey(assistant.JourneyQuery{
+		Origin:      stopOriginName,
+		Destination: stopDestinationName,
+		Language:    p.Language(),
+	})
+	if err != nil {
+		return errorResponse(f, err)
+	}
+	return speechResponse(answer.Utterance)
+}
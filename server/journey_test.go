@@ -0,0 +1,36 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestDialogFlowJourney(t *testing.T) {
+
+	hook := newTestWebhook(t, nil, time.Second)
+
+	tests := []struct {
+		name       string
+		parameters map[string]interface{}
+		want       string
+	}{
+		{"journey", map[string]interface{}{
+			StopOriginKey:      map[string]interface{}{StopNameKey: "Ouchy"},
+			StopDestinationKey: map[string]interface{}{StopNameKey: "Gare"},
+		}, "Depuis Ouchy, prenez la ligne 2 en direction de Gare jusqu'à Gare."},
+		{"already there", map[string]interface{}{
+			StopOriginKey:      map[string]interface{}{StopNameKey: "Flon"},
+			StopDestinationKey: map[string]interface{}{StopNameKey: "Flon"},
+		}, "Vous êtes déjà à Flon."},
+		{"no destination", map[string]interface{}{
+			StopOriginKey: map[string]interface{}{StopNameKey: "Ouchy"},
+		}, "Une erreur est survenue sur nos serveurs. Veuillez nous excuser pour ce contre-temps."},
+	}
+
+	for _, test := range tests {
+		resp := dialogFlowExchange(t, hook, dialogFlowRequest(dialogFlowJourneyIntent, test.parameters))
+		if resp.Text != test.want {
+			t.Errorf("%s: expected %q, got %q", test.name, test.want, resp.Text)
+		}
+	}
+}
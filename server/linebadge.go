@@ -0,0 +1,68 @@
+package main
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/png"
+	"log"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+const lineBadgeSize = 192
+
+// parseLineColours reads the colours of the lines from a comma separated
+// list of line short names and hexadecimal colours.
+func parseLineColours(value string) (map[string]color.RGBA, error) {
+
+	colours := map[string]color.RGBA{}
+	for _, entry := range strings.Split(value, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+
+		parts := strings.SplitN(entry, ":", 2)
+		if len(parts) != 2 {
+			return nil, fmt.Errorf("invalid line colour %q", entry)
+		}
+
+		rgb, err := strconv.ParseUint(strings.TrimPrefix(parts[1], "#"), 16, 32)
+		if err != nil || len(strings.TrimPrefix(parts[1], "#")) != 6 {
+			return nil, fmt.Errorf("invalid colour of line %s: %q", parts[0], parts[1])
+		}
+		colours[parts[0]] = color.RGBA{R: uint8(rgb >> 16), G: uint8(rgb >> 8), B: uint8(rgb), A: 0xff}
+	}
+	return colours, nil
+}
+
+// lineBadgeURL returns the URL of the badge of the line if its colour is known
+func (h *webhook) lineBadgeURL(shortName string) (string, bool) {
+	if _, hasColour := h.lineColours[shortName]; !hasColour || h.publicURL == "" {
+		return "", false
+	}
+	return fmt.Sprintf("%s/lines/%s/badge.png", strings.TrimRight(h.publicURL, "/"), url.PathEscape(shortName)), true
+}
+
+// lineBadgeHandler draws a square of the colour of the line
+func (h *webhook) lineBadgeHandler(w http.ResponseWriter, r *http.Request) {
+
+	colour, hasColour := h.lineColours[r.URL.Query().Get(":line")]
+	if !hasColour {
+		http.NotFound(w, r)
+		return
+	}
+
+	badge := image.NewRGBA(image.Rect(0, 0, lineBadgeSize, lineBadgeSize))
+	draw.Draw(badge, badge.Bounds(), &image.Uniform{C: colour}, image.Point{}, draw.Src)
+
+	w.Header().Set("Content-Type", "image/png")
+	w.Header().Set("Cache-Control", "public, max-age=86400")
+	if err := png.Encode(w, badge); err != nil {
+		log.Printf("Can not encode the line badge: %v\n", err)
+	}
+}
@@ -0,0 +1,48 @@
+package main
+
+import (
+	"image/color"
+	"image/png"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestLineBadges(t *testing.T) {
+
+	colours, err := parseLineColours("9:E2001A, 16:#00A0E2")
+	if err != nil {
+		t.Fatal(err)
+	}
+	hook := &webhook{publicURL: "https://example.com/", lineColours: colours}
+
+	if badge, hasBadge := hook.lineBadgeURL("9"); !hasBadge || badge != "https://example.com/lines/9/badge.png" {
+		t.Errorf("unexpected badge of line 9: %q", badge)
+	}
+	if _, hasBadge := hook.lineBadgeURL("1"); hasBadge {
+		t.Errorf("line 1 has no colour")
+	}
+	if _, hasBadge := (&webhook{lineColours: colours}).lineBadgeURL("9"); hasBadge {
+		t.Errorf("no badge is shown without the public URL")
+	}
+
+	w := httptest.NewRecorder()
+	hook.lineBadgeHandler(w, httptest.NewRequest(http.MethodGet, "/lines/16/badge.png?:line=16", nil))
+	badge, err := png.Decode(w.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := color.RGBAModel.Convert(badge.At(0, 0)); got != (color.RGBA{R: 0x00, G: 0xa0, B: 0xe2, A: 0xff}) {
+		t.Errorf("unexpected colour of line 16: %v", got)
+	}
+
+	w = httptest.NewRecorder()
+	hook.lineBadgeHandler(w, httptest.NewRequest(http.MethodGet, "/lines/1/badge.png?:line=1", nil))
+	if w.Code != http.StatusNotFound {
+		t.Errorf("expected 404 for line 1, got %d", w.Code)
+	}
+
+	if _, err := parseLineColours("9:red"); err == nil {
+		t.Errorf("expected an invalid colour")
+	}
+}
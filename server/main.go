@@ -2,34 +2,52 @@ package main
 
 import (
 	"bytes"
+	"context"
 	"encoding/gob"
 	"encoding/hex"
 	"fmt"
 	"log"
 	"net/http"
 	"os"
+	"os/signal"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
 
 	"github.com/gophersch/tlgo"
 	"github.com/gorilla/pat"
+	"github.com/yageek/tl-ai/assistant"
 	"github.com/yageek/tl-ai/dataprovider"
+	"github.com/yageek/tl-ai/favourites"
+	"github.com/yageek/tl-ai/realtime"
+	"github.com/yageek/tl-ai/reminders"
+	"github.com/yageek/tl-ai/search"
 	"github.com/yageek/tl-ai/storage"
+	"golang.org/x/oauth2/google"
 )
 
 var (
-	store *storage.Store
-
 	USERNAME string
 	PASSWORD string
-
-	tlClient *tlgo.Client
 )
 
 const (
 	lastDataCache = "cache/apidata.gob"
+	// shutdownTimeout is the time given to the requests in flight on
+	// shutdown, App Engine killing the instance 10 seconds after SIGTERM
+	shutdownTimeout = 8 * time.Second
+	// defaultUpstreamTimeout leaves time to answer within the 5 seconds
+	// given by Dialogflow to the webhook
+	defaultUpstreamTimeout = 3 * time.Second
 )
 
 func main() {
 
+	// Stopped by App Engine with SIGTERM
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
 	// Configuration
 	USERNAME = os.Getenv("USERNAME")
 	PASSWORD = os.Getenv("PASSWORD")
@@ -52,15 +70,97 @@ func main() {
 	}
 
 	// Store
-	store = storage.NewStore(apiData)
+	store := storage.NewStore(apiData)
+
+	// Search graph shared by all the requests
+	graph, err := search.NewBFS(*store)
+	if err != nil {
+		log.Fatalf("Can not build the search graph: %s\n", err)
+	}
+
+	// Itineraries on the timetable, when the embedded data holds one
+	timetable, err := search.NewRouter(*store, search.DefaultRouterOptions)
+	if err == search.ErrNoTimetable {
+		log.Printf("No timetable embedded, the itineraries are not timed\n")
+		timetable = nil
+	} else if err != nil {
+		log.Fatalf("Can not build the router: %s\n", err)
+	}
+
+	// Real time departures, replayed from fixtures to run without the TL API
+	var provider realtime.DeparturesProvider = realtime.NewTLProvider(tlgo.NewClient())
+	if path := os.Getenv("DEPARTURES_FIXTURES"); path != "" {
+		fixtures, err := realtime.OpenFixtures(path)
+		if err != nil {
+			log.Fatalf("Can not load the departure fixtures at %s: %s\n", path, err)
+		}
+		log.Printf("Replaying the departures of %s\n", path)
+		provider = fixtures
+	} else if path := os.Getenv("DEPARTURES_RECORD"); path != "" {
+		log.Printf("Recording the departures in %s\n", path)
+		provider = realtime.NewRecorder(provider, path)
+	}
 
-	// Main client
-	tlClient = tlgo.NewClient()
+	// Business logic shared by all the voice platforms
+	core := assistant.New(store, graph, timetable, provider)
+
+	// Favourites are kept in memory unless a BoltDB file is provided
+	var favouriteStore favourites.Store
+	if path := os.Getenv("FAVOURITES_PATH"); path != "" {
+		boltStore, err := favourites.OpenBoltStore(path)
+		if err != nil {
+			log.Fatalf("Can not open the favourites at %s: %s\n", path, err)
+		}
+		favouriteStore = boltStore
+	} else {
+		log.Printf("FAVOURITES_PATH is not set, the favourites are lost on restart")
+		favouriteStore = favourites.NewMemoryStore()
+	}
+
+	// Reminders of the favourites, configured in a JSON file
+	var background sync.WaitGroup
+	if path := os.Getenv("REMINDERS_PATH"); path != "" {
+		scheduler := newReminderScheduler(path, os.Getenv("REMINDERS_SINK"), core, favouriteStore)
+		background.Add(1)
+		go func() {
+			defer background.Done()
+			scheduler.Run(ctx)
+		}()
+	}
+
+	// Rich responses
+	lineColours, err := parseLineColours(os.Getenv("LINE_COLOURS"))
+	if err != nil {
+		log.Fatalf("Can not read LINE_COLOURS: %s\n", err)
+	}
+
+	// Deadline of the TL API calls of each request
+	upstreamTimeout := defaultUpstreamTimeout
+	if value := os.Getenv("UPSTREAM_TIMEOUT"); value != "" {
+		upstreamTimeout, err = time.ParseDuration(value)
+		if err != nil {
+			log.Fatalf("Can not read UPSTREAM_TIMEOUT: %s\n", err)
+		}
+	}
 
 	// Main app
+	hook := &webhook{
+		core:        core,
+		favourites:  favouriteStore,
+		timeout:     upstreamTimeout,
+		publicURL:   os.Getenv("PUBLIC_URL"),
+		lineColours: lineColours,
+	}
 	router := pat.New()
 
-	router.Post("/dialogflow_interactions", basicAuth(USERNAME, PASSWORD, dialogFlowHandler))
+	router.Post("/dialogflow_interactions", basicAuth(USERNAME, PASSWORD, hook.dialogFlowHandler))
+	router.Get("/lines/{line}/badge.png", hook.lineBadgeHandler)
+
+	alexaSkillID := os.Getenv("ALEXA_SKILL_ID")
+	if alexaSkillID == "" {
+		log.Printf("ALEXA_SKILL_ID is not set, requests of any skill are accepted")
+	}
+	router.Post("/alexa", alexaAuth(newAlexaVerifier(newCertificateSource(), alexaSkillID), hook.alexaHandler))
 
 	port := os.Getenv("PORT")
 	if port == "" {
@@ -68,6 +168,65 @@ func main() {
 		log.Printf("Defaulting to port %s", port)
 	}
 
+	server := &http.Server{Addr: fmt.Sprintf(":%s", port), Handler: router}
+	background.Add(1)
+	go func() {
+		defer background.Done()
+		<-ctx.Done()
+
+		log.Printf("Shutting down...")
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+		defer cancel()
+		if err := server.Shutdown(shutdownCtx); err != nil {
+			log.Printf("The requests in flight have been interrupted: %s\n", err)
+		}
+	}()
+
 	log.Printf("Listening on port %s", port)
-	log.Fatal(http.ListenAndServe(fmt.Sprintf(":%s", port), router))
+	if err := server.ListenAndServe(); err != http.ErrServerClosed {
+		log.Fatal(err)
+	}
+
+	// The favourites are closed once nothing uses them anymore
+	background.Wait()
+	if err := favouriteStore.Close(); err != nil {
+		log.Printf("Can not close the favourites: %s\n", err)
+	}
+}
+
+// newReminderScheduler schedules the reminders of the file. The
+// notifications are sent through the sink, a webhook URL, "actions" for
+// Actions on Google or the logs by default.
+func newReminderScheduler(path string, sinkName string, departures reminders.Departures, store favourites.Store) *reminders.Scheduler {
+
+	file, err := os.Open(path)
+	if err != nil {
+		log.Fatalf("Can not open the reminders: %s\n", err)
+	}
+	defer file.Close()
+
+	list, err := reminders.LoadReminders(file)
+	if err != nil {
+		log.Fatalf("Can not load the reminders: %s\n", err)
+	}
+
+	var sink reminders.Sink = reminders.LogSink{}
+	switch {
+	case sinkName == "actions":
+		tokenSource, err := google.DefaultTokenSource(context.Background(), reminders.ActionsScope)
+		if err != nil {
+			log.Fatalf("Can not get the credentials to notify through Actions on Google: %s\n", err)
+		}
+		sink = reminders.ActionsSink{Intent: dialogFlowMyBusIntent, TokenSource: tokenSource}
+	case strings.HasPrefix(sinkName, "https://") || strings.HasPrefix(sinkName, "http://"):
+		sink = reminders.WebhookSink{URL: sinkName}
+	}
+
+	scheduler := reminders.NewScheduler(departures, store, sink, reminders.SystemClock{}, time.Minute)
+	for _, reminder := range list {
+		scheduler.Add(reminder)
+	}
+
+	log.Printf("Scheduling %d reminders\n", len(list))
+	return scheduler
 }
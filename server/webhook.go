@@ -0,0 +1,219 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/yageek/tl-ai/assistant"
+	"github.com/yageek/tl-ai/i18n"
+)
+
+// googlePlatform is the platform of the messages for Actions on Google
+const googlePlatform = "ACTIONS_ON_GOOGLE"
+
+// Models of the Dialogflow v2 webhook
+// See https://cloud.google.com/dialogflow/docs/fulfillment-webhook
+
+type fullfillment struct {
+	ResponseID      string                      `json:"responseId"`
+	Session         string                      `json:"session"`
+	QueryResult     queryResult                 `json:"queryResult"`
+	OriginalRequest originalDetectIntentRequest `json:"originalDetectIntentRequest"`
+}
+
+type queryResult struct {
+	Query              string                 `json:"queryText"`
+	LanguageCode       string                 `json:"languageCode"`
+	Action             string                 `json:"action"`
+	Parameters         map[string]interface{} `json:"parameters"`
+	AllRequiredPresent bool                   `json:"allRequiredParamsPresent"`
+	FulfillmentText    string                 `json:"fulfillmentText"`
+	Intent             intent                 `json:"intent"`
+	Confidence         float32                `json:"intentDetectionConfidence"`
+	OutputContexts     []outputContext        `json:"outputContexts"`
+}
+
+type intent struct {
+	Name        string `json:"name"`
+	DisplayName string `json:"displayName"`
+}
+
+type outputContext struct {
+	Name          string                 `json:"name"`
+	LifespanCount int                    `json:"lifespanCount"`
+	Parameters    map[string]interface{} `json:"parameters,omitempty"`
+}
+
+type originalDetectIntentRequest struct {
+	Source  string         `json:"source"`
+	Version string         `json:"version"`
+	Payload requestPayload `json:"payload"`
+}
+
+// requestPayload is the payload sent by Actions on Google
+type requestPayload struct {
+	User         user         `json:"user"`
+	Conversation conversation `json:"conversation"`
+	Device       device       `json:"device"`
+	Surface      surface      `json:"surface"`
+	Inputs       []input      `json:"inputs"`
+}
+
+type user struct {
+	UserID string `json:"userId"`
+	Locale string `json:"locale"`
+}
+
+type conversation struct {
+	ConversationID string `json:"conversationId"`
+	Type           string `json:"type"`
+}
+
+type device struct {
+	Location *location `json:"location"`
+}
+
+type location struct {
+	Coordinates coordinates `json:"coordinates"`
+}
+
+type coordinates struct {
+	Latitude  float64 `json:"latitude"`
+	Longitude float64 `json:"longitude"`
+}
+
+type surface struct {
+	Capabilities []capability `json:"capabilities"`
+}
+
+type capability struct {
+	Name string `json:"name"`
+}
+
+type input struct {
+	Intent    string     `json:"intent"`
+	Arguments []argument `json:"arguments"`
+}
+
+type argument struct {
+	Name      string `json:"name"`
+	TextValue string `json:"textValue"`
+}
+
+type fullFillementResponse struct {
+	Text                string                 `json:"fulfillmentText"`
+	FulfillmentMessages []message              `json:"fulfillmentMessages,omitempty"`
+	Source              string                 `json:"source,omitempty"`
+	Payload             map[string]interface{} `json:"payload,omitempty"`
+	OutputContexts      []outputContext        `json:"outputContexts,omitempty"`
+	FollowupEventInput  *followupEventInput    `json:"followupEventInput,omitempty"`
+	// SSML is the spoken form of Text, the escaped Text when empty
+	SSML string `json:"-"`
+}
+
+// message is a rich response message. Only one of its fields is set.
+type message struct {
+	Platform        string           `json:"platform,omitempty"`
+	Text            *textMessage     `json:"text,omitempty"`
+	Card            *card            `json:"card,omitempty"`
+	SimpleResponses *simpleResponses `json:"simpleResponses,omitempty"`
+}
+
+type textMessage struct {
+	Text []string `json:"text"`
+}
+
+type simpleResponses struct {
+	SimpleResponses []simpleResponse `json:"simpleResponses"`
+}
+
+type simpleResponse struct {
+	SSML        string `json:"ssml,omitempty"`
+	DisplayText string `json:"displayText,omitempty"`
+}
+
+type card struct {
+	Title    string       `json:"title"`
+	Subtitle string       `json:"subtitle,omitempty"`
+	ImageURI string       `json:"imageUri,omitempty"`
+	Buttons  []cardButton `json:"buttons,omitempty"`
+}
+
+type cardButton struct {
+	Text     string `json:"text"`
+	Postback string `json:"postback,omitempty"`
+}
+
+type followupEventInput struct {
+	Name         string                 `json:"name"`
+	LanguageCode string                 `json:"languageCode,omitempty"`
+	Parameters   map[string]interface{} `json:"parameters,omitempty"`
+}
+
+// context returns the active context with the provided short name
+func (q queryResult) context(name string) (outputContext, bool) {
+	for _, ctx := range q.OutputContexts {
+		if strings.HasSuffix(ctx.Name, "/contexts/"+name) {
+			return ctx, true
+		}
+	}
+	return outputContext{}, false
+}
+
+// contextName returns the full name of a context of the session
+func (f fullfillment) contextName(name string) string {
+	return fmt.Sprintf("%s/contexts/%s", f.Session, name)
+}
+
+// speech returns the utterance of the response
+func (r fullFillementResponse) speech() assistant.Utterance {
+	if r.SSML == "" {
+		return assistant.Plain(r.Text)
+	}
+	return assistant.Utterance{Text: r.Text, SSML: r.SSML}
+}
+
+// withSpeech adds the spoken form of the response for Actions on Google.
+// The other platforms keep showing the text.
+func (r fullFillementResponse) withSpeech() fullFillementResponse {
+
+	// The rich responses hold their own spoken form
+	if _, hasGooglePayload := r.Payload[googlePayloadKey]; r.Text == "" || hasGooglePayload {
+		return r
+	}
+
+	messages := r.FulfillmentMessages
+	if len(messages) == 0 {
+		messages = []message{{Text: &textMessage{Text: []string{r.Text}}}}
+	}
+
+	speech := r.speech()
+	spoken := message{
+		Platform: googlePlatform,
+		SimpleResponses: &simpleResponses{
+			SimpleResponses: []simpleResponse{{SSML: speech.Speak(), DisplayText: speech.Text}},
+		},
+	}
+	r.FulfillmentMessages = append([]message{spoken}, messages...)
+	return r
+}
+
+// userID returns the ID of the user, empty for the anonymous users
+func (f fullfillment) userID() string {
+	return f.OriginalRequest.Payload.User.UserID
+}
+
+// printer returns the printer of the language of the request
+func (f fullfillment) printer() i18n.Printer {
+	return i18n.NewPrinter(i18n.ParseLanguage(f.QueryResult.LanguageCode))
+}
+
+// deviceLocation returns the position of the device if the user
+// granted the location permission.
+func (f fullfillment) deviceLocation() (coordinates, bool) {
+	loc := f.OriginalRequest.Payload.Device.Location
+	if loc == nil || (loc.Coordinates.Latitude == 0 && loc.Coordinates.Longitude == 0) {
+		return coordinates{}, false
+	}
+	return loc.Coordinates, true
+}
@@ -0,0 +1,82 @@
+package main
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gophersch/tlgo"
+	"github.com/yageek/tl-ai/assistant"
+	"github.com/yageek/tl-ai/dataprovider"
+	"github.com/yageek/tl-ai/favourites"
+	"github.com/yageek/tl-ai/realtime"
+	"github.com/yageek/tl-ai/search"
+	"github.com/yageek/tl-ai/storage"
+)
+
+// testFixtures are the departures of line 2 from Flon in both directions
+const testFixtures = `[
+	{"stopId": "flon", "lineId": "L2", "wayback": false, "waitingTimes": ["2m", "9m", "17m"]},
+	{"stopId": "flon", "lineId": "L2", "wayback": true, "waitingTimes": ["4m", "12m"]}
+]`
+
+// testStore holds line 2 going from Ouchy to Gare through the two
+// Bessières stops and Flon, and back
+func testStore() *storage.Store {
+	return storage.NewStore(dataprovider.APIRawData{
+		Stops: []tlgo.Stop{
+			{ID: "ouchy", Name: "Ouchy", LinesShortName: []string{"2"}},
+			{ID: "bessieres-nord", Name: "Bessières Nord", LinesShortName: []string{"2"}},
+			{ID: "bessieres-sud", Name: "Bessières Sud", LinesShortName: []string{"2"}},
+			{ID: "flon", Name: "Flon", LinesShortName: []string{"2"}},
+			{ID: "gare", Name: "Gare", LinesShortName: []string{"2"}},
+		},
+		Lines: []tlgo.Line{
+			{ID: "L2", Name: "Ligne 2", ShortName: "2"},
+		},
+		RoutesByLineID: map[string][]tlgo.Route{
+			"L2": {
+				{ID: "r2", CityOriginStopName: "Ouchy", CityDestination: "Gare", CityDestinationStopName: "Gare", MainRoute: true},
+				{ID: "r2b", CityOriginStopName: "Gare", CityDestination: "Ouchy", CityDestinationStopName: "Ouchy", MainRoute: true, Wayback: true},
+			},
+		},
+		RoutesDetailsByRouteID: map[string]tlgo.RouteDetails{
+			"r2":  {LineID: "L2", Stops: routeStops("Ouchy", "Bessières Sud", "Bessières Nord", "Flon", "Gare")},
+			"r2b": {LineID: "L2", Wayback: true, Stops: routeStops("Gare", "Flon", "Bessières Nord", "Bessières Sud", "Ouchy")},
+		},
+	})
+}
+
+func routeStops(names ...string) []tlgo.StopRouteDetails {
+	stops := make([]tlgo.StopRouteDetails, len(names))
+	for i, name := range names {
+		stops[i] = tlgo.StopRouteDetails{StopAreaName: name}
+	}
+	return stops
+}
+
+// newTestWebhook answers with the departures of the provider, or of the
+// test fixtures when it is nil.
+func newTestWebhook(t *testing.T, provider realtime.DeparturesProvider, timeout time.Duration) *webhook {
+	t.Helper()
+
+	if provider == nil {
+		fixtures, err := realtime.LoadFixtures(strings.NewReader(testFixtures))
+		if err != nil {
+			t.Fatal(err)
+		}
+		provider = fixtures
+	}
+
+	store := testStore()
+	graph, err := search.NewBFSWithOptions(*store, search.GraphOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return &webhook{
+		core:       assistant.New(store, graph, nil, provider),
+		favourites: favourites.NewMemoryStore(),
+		timeout:    timeout,
+	}
+}
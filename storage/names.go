@@ -0,0 +1,250 @@
+package storage
+
+import (
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/gophersch/tlgo"
+	"golang.org/x/text/unicode/norm"
+)
+
+const (
+	// minMatchScore is the score under which a stop name is not considered as a candidate
+	minMatchScore = 0.5
+	// minLineMatchScore is stricter as line names are very short
+	minLineMatchScore = 0.75
+	// containedWordsScore is the score of a name holding all the searched words
+	containedWordsScore = 0.8
+)
+
+var (
+	// nameAbbreviations are expanded before comparing names
+	nameAbbreviations = map[string]string{
+		"st":  "saint",
+		"ste": "sainte",
+		"av":  "avenue",
+		"pl":  "place",
+	}
+
+	// nameStopWords are ignored when comparing names
+	nameStopWords = map[string]bool{
+		"de":  true,
+		"du":  true,
+		"des": true,
+		"la":  true,
+		"le":  true,
+		"les": true,
+		"l":   true,
+		"d":   true,
+	}
+)
+
+// StopMatch is a stop whose name matches a searched name
+type StopMatch struct {
+	Stop tlgo.Stop
+	// Score is the similarity between the names, 1 being an exact match
+	Score float64
+}
+
+// LineMatch is a line whose name matches a searched name
+type LineMatch struct {
+	Line tlgo.Line
+	// Score is the similarity between the names, 1 being an exact match
+	Score float64
+}
+
+// normalizedName is a name prepared for comparisons
+type normalizedName struct {
+	key      string
+	words    map[string]bool
+	trigrams map[string]int
+}
+
+func newNormalizedName(name string) normalizedName {
+	key := normalizeName(name)
+
+	words := map[string]bool{}
+	for _, word := range strings.Fields(key) {
+		words[word] = true
+	}
+	return normalizedName{key: key, words: words, trigrams: trigrams(key)}
+}
+
+// normalizeName folds a name to compare it: diacritics are removed, the
+// name is lower cased, punctuation is ignored, abbreviations are expanded
+// and the words are sorted so their order does not matter.
+func normalizeName(name string) string {
+
+	folded := strings.Builder{}
+	for _, r := range norm.NFKD.String(name) {
+		switch {
+		case unicode.Is(unicode.Mn, r):
+			// Diacritic
+		case unicode.IsLetter(r) || unicode.IsDigit(r):
+			folded.WriteRune(unicode.ToLower(r))
+		default:
+			folded.WriteRune(' ')
+		}
+	}
+
+	words := []string{}
+	for _, word := range strings.Fields(folded.String()) {
+		if expanded, isAbbreviation := nameAbbreviations[word]; isAbbreviation {
+			word = expanded
+		}
+		if !nameStopWords[word] {
+			words = append(words, word)
+		}
+	}
+	sort.Strings(words)
+
+	return strings.Join(words, " ")
+}
+
+func trigrams(key string) map[string]int {
+	padded := []rune("  " + key + " ")
+	grams := make(map[string]int, len(padded))
+	for i := 0; i+3 <= len(padded); i++ {
+		grams[string(padded[i:i+3])]++
+	}
+	return grams
+}
+
+// similarity scores a searched name against a known one between 0 and 1
+// using the best of their trigram and edit distance similarities. Known
+// names holding all the searched words score at least containedWordsScore.
+func similarity(a, b normalizedName) float64 {
+
+	if a.key == b.key {
+		return 1
+	}
+
+	containsWords := len(a.words) > 0
+	for word := range a.words {
+		containsWords = containsWords && b.words[word]
+	}
+
+	common, total := 0, 0
+	for gram, count := range a.trigrams {
+		if other := b.trigrams[gram]; other < count {
+			common += other
+		} else {
+			common += count
+		}
+		total += count
+	}
+	for _, count := range b.trigrams {
+		total += count
+	}
+
+	trigramScore := 0.0
+	if total > 0 {
+		trigramScore = 2 * float64(common) / float64(total)
+	}
+
+	ra, rb := []rune(a.key), []rune(b.key)
+	longest := len(ra)
+	if len(rb) > longest {
+		longest = len(rb)
+	}
+
+	editScore := 0.0
+	if longest > 0 {
+		editScore = 1 - float64(levenshtein(ra, rb))/float64(longest)
+	}
+
+	score := trigramScore
+	if editScore > score {
+		score = editScore
+	}
+	if containsWords && containedWordsScore > score {
+		score = containedWordsScore
+	}
+	return score
+}
+
+func levenshtein(a, b []rune) int {
+	previous := make([]int, len(b)+1)
+	current := make([]int, len(b)+1)
+	for j := range previous {
+		previous[j] = j
+	}
+
+	for i := 1; i <= len(a); i++ {
+		current[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			current[j] = previous[j] + 1
+			if current[j-1]+1 < current[j] {
+				current[j] = current[j-1] + 1
+			}
+			if previous[j-1]+cost < current[j] {
+				current[j] = previous[j-1] + cost
+			}
+		}
+		previous, current = current, previous
+	}
+	return previous[len(b)]
+}
+
+// FindStops returns up to count stops whose name match the provided one,
+// the best match first. No stop is returned when count is not positive.
+func (s *Store) FindStops(name string, count int) ([]StopMatch, error) {
+
+	searched := newNormalizedName(name)
+
+	matches := []StopMatch{}
+	for i, stopName := range s.stopNames {
+		if score := similarity(searched, stopName); score >= minMatchScore {
+			matches = append(matches, StopMatch{Stop: s.stops[i], Score: score})
+		}
+	}
+
+	if len(matches) == 0 {
+		return []StopMatch{}, ErrNotFound
+	}
+
+	sort.SliceStable(matches, func(i, j int) bool {
+		return matches[i].Score > matches[j].Score
+	})
+	if count < 0 {
+		count = 0
+	}
+	if len(matches) > count {
+		matches = matches[:count]
+	}
+	return matches, nil
+}
+
+// FindLines returns up to count lines whose short name match the provided
+// one, the best match first. No line is returned when count is not positive.
+func (s *Store) FindLines(name string, count int) ([]LineMatch, error) {
+
+	searched := newNormalizedName(name)
+
+	matches := []LineMatch{}
+	for i, lineName := range s.lineNames {
+		if score := similarity(searched, lineName); score >= minLineMatchScore {
+			matches = append(matches, LineMatch{Line: s.lines[i], Score: score})
+		}
+	}
+
+	if len(matches) == 0 {
+		return []LineMatch{}, ErrNotFound
+	}
+
+	sort.SliceStable(matches, func(i, j int) bool {
+		return matches[i].Score > matches[j].Score
+	})
+	if count < 0 {
+		count = 0
+	}
+	if len(matches) > count {
+		matches = matches[:count]
+	}
+	return matches, nil
+}
@@ -0,0 +1,103 @@
+package storage
+
+import (
+	"testing"
+
+	"github.com/gophersch/tlgo"
+	"github.com/yageek/tl-ai/dataprovider"
+)
+
+func TestNormalizeName(t *testing.T) {
+
+	tests := []struct {
+		name string
+		want string
+	}{
+		{"St-François", "francois saint"},
+		{"Lausanne, Gare", "gare lausanne"},
+		{"Place de l'Europe", "europe place"},
+		{"AV. DE RHODANIE", "avenue rhodanie"},
+		{"Bel-Air", "air bel"},
+	}
+
+	for _, test := range tests {
+		if got := normalizeName(test.name); got != test.want {
+			t.Errorf("%s: expected %q, got %q", test.name, test.want, got)
+		}
+	}
+}
+
+func TestFindStops(t *testing.T) {
+
+	store := NewStore(dataprovider.APIRawData{
+		Stops: []tlgo.Stop{
+			{ID: "1", Name: "St-François"},
+			{ID: "2", Name: "Lausanne, Gare"},
+			{ID: "3", Name: "Renens, Gare"},
+			{ID: "4", Name: "Bel-Air"},
+			{ID: "5", Name: "Ouchy-Olympique"},
+		},
+		Lines: []tlgo.Line{
+			{ID: "L9", ShortName: "9"},
+			{ID: "L19", ShortName: "19"},
+			{ID: "M2", ShortName: "m2"},
+		},
+	})
+
+	tests := []struct {
+		searched string
+		want     string
+	}{
+		{"saint francois", "St-François"},
+		{"SAINT-FRANÇOIS", "St-François"},
+		{"gare de Lausanne", "Lausanne, Gare"},
+		{"bel air", "Bel-Air"},
+		{"Ouchy Olympic", "Ouchy-Olympique"},
+	}
+
+	for _, test := range tests {
+		matches, err := store.FindStops(test.searched, 3)
+		if err != nil {
+			t.Errorf("%s: %v", test.searched, err)
+			continue
+		}
+		if matches[0].Stop.Name != test.want {
+			t.Errorf("%s: expected %s, got %s", test.searched, test.want, matches[0].Stop.Name)
+		}
+	}
+
+	// Both stations hold the searched word
+	matches, err := store.FindStops("gare", 3)
+	if err != nil || len(matches) != 2 {
+		t.Errorf("expected the two stations, got %+v (%v)", matches, err)
+	}
+
+	if _, err := store.FindStops("Zurich Flughafen", 3); err != ErrNotFound {
+		t.Errorf("expected %v, got %v", ErrNotFound, err)
+	}
+
+	lines, err := store.FindLines("M2", 1)
+	if err != nil || lines[0].Line.ID != "M2" {
+		t.Errorf("expected the line m2, got %+v (%v)", lines, err)
+	}
+	lines, err = store.FindLines("9", 1)
+	if err != nil || lines[0].Line.ID != "L9" {
+		t.Errorf("expected the line 9, got %+v (%v)", lines, err)
+	}
+
+	// Short numeric names only match exactly
+	lines, err = store.FindLines("19", 3)
+	if err != nil || len(lines) != 1 || lines[0].Line.ID != "L19" {
+		t.Errorf("expected the line 19 only, got %+v (%v)", lines, err)
+	}
+	if _, err := store.FindLines("1", 3); err != ErrNotFound {
+		t.Errorf("expected %v, got %v", ErrNotFound, err)
+	}
+
+	if matches, err := store.FindStops("gare", -1); err != nil || len(matches) != 0 {
+		t.Errorf("expected no stop for a negative count, got %+v (%v)", matches, err)
+	}
+	if lines, err := store.FindLines("9", -1); err != nil || len(lines) != 0 {
+		t.Errorf("expected no line for a negative count, got %+v (%v)", lines, err)
+	}
+}
@@ -5,12 +5,28 @@ import (
 
 	"github.com/gophersch/tlgo"
 	"github.com/yageek/tl-ai/dataprovider"
+	"github.com/yageek/tl-ai/geo"
 )
 
 var (
 	ErrNotFound = errors.New("Element not found")
 )
 
+const (
+	// stopsGridCellSize is the size in meters of the cells of the stops spatial index
+	stopsGridCellSize = 500
+	// nearestStopsRadius is the distance in meters beyond which a stop is
+	// not considered near, the users far from the network having none
+	nearestStopsRadius = 5000
+)
+
+// StopDistance is a stop found near a position
+type StopDistance struct {
+	Stop tlgo.Stop
+	// Distance is the distance in meters to the position
+	Distance float64
+}
+
 type Store struct {
 	stops                  []tlgo.Stop
 	lines                  []tlgo.Line
@@ -21,6 +37,10 @@ type Store struct {
 	linesByName            map[string]tlgo.Line
 	routesDetailsByRouteID map[string]tlgo.RouteDetails
 	routesByRouteID        map[string]tlgo.Route
+	tripsByRouteID         map[string][]dataprovider.Trip
+	stopsGrid              *geo.Grid
+	stopNames              []normalizedName
+	lineNames              []normalizedName
 }
 
 func NewStore(data dataprovider.APIRawData) *Store {
@@ -35,16 +55,24 @@ func NewStore(data dataprovider.APIRawData) *Store {
 		linesByRouteID:         map[string]tlgo.Line{},
 		linesByName:            map[string]tlgo.Line{},
 		routesByRouteID:        map[string]tlgo.Route{},
+		tripsByRouteID:         data.TripsByRouteID,
 	}
 
 	// Build stop index
-	for _, stop := range data.Stops {
+	points := make([]geo.Point, len(data.Stops))
+	st.stopNames = make([]normalizedName, len(data.Stops))
+	for i, stop := range data.Stops {
 		st.stopsByStopName[stop.Name] = stop
+		points[i] = geo.Point{Lat: stop.Lat, Lng: stop.Lng}
+		st.stopNames[i] = newNormalizedName(stop.Name)
 	}
+	st.stopsGrid = geo.NewGrid(points, stopsGridCellSize)
 
-	for _, line := range data.Lines {
+	st.lineNames = make([]normalizedName, len(data.Lines))
+	for i, line := range data.Lines {
 		st.linesByLineID[line.ID] = line
 		st.linesByName[line.ShortName] = line
+		st.lineNames[i] = newNormalizedName(line.ShortName)
 	}
 	// build lineRoute index
 	for lineID, routes := range data.RoutesByLineID {
@@ -87,6 +115,15 @@ func (s *Store) GetRoutesDetailsForRouteID(routeID string) (tlgo.RouteDetails, e
 	return tlgo.RouteDetails{}, ErrNotFound
 }
 
+func (s *Store) GetRouteByID(routeID string) (tlgo.Route, error) {
+
+	route, hasRoute := s.routesByRouteID[routeID]
+	if hasRoute {
+		return route, nil
+	}
+	return tlgo.Route{}, ErrNotFound
+}
+
 func (s *Store) GetLineForRouteID(routeID string) (tlgo.Line, error) {
 
 	line, hasLine := s.linesByRouteID[routeID]
@@ -105,6 +142,22 @@ func (s *Store) GetStopByName(name string) (tlgo.Stop, error) {
 	return tlgo.Stop{}, ErrNotFound
 }
 
+// GetNearestStops returns the count stops closest to the position, closest
+// first. ErrNotFound is returned when no stop is within a few kilometers.
+func (s *Store) GetNearestStops(lat, lng float64, count int) ([]StopDistance, error) {
+
+	neighbours := s.stopsGrid.Nearest(geo.Point{Lat: lat, Lng: lng}, count, nearestStopsRadius)
+	if len(neighbours) == 0 {
+		return []StopDistance{}, ErrNotFound
+	}
+
+	stops := make([]StopDistance, len(neighbours))
+	for i, neighbour := range neighbours {
+		stops[i] = StopDistance{Stop: s.stops[neighbour.Index], Distance: neighbour.Distance}
+	}
+	return stops, nil
+}
+
 func (s *Store) GetLineByName(name string) (tlgo.Line, error) {
 
 	line, hasLine := s.linesByName[name]
@@ -117,3 +170,12 @@ func (s *Store) GetLineByName(name string) (tlgo.Line, error) {
 func (s *Store) GetRoutesDetailsByRouteID() (map[string]tlgo.RouteDetails, error) {
 	return s.routesDetailsByRouteID, nil
 }
+
+func (s *Store) GetTripsForRouteID(routeID string) ([]dataprovider.Trip, error) {
+
+	trips, hasTrips := s.tripsByRouteID[routeID]
+	if hasTrips {
+		return trips, nil
+	}
+	return []dataprovider.Trip{}, ErrNotFound
+}